@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/jessevdk/go-flags"
 
@@ -25,9 +26,19 @@ var (
 
 // Setup subcommands
 
+type FormatOption struct {
+	Format string `long:"format" choice:"text" choice:"json" choice:"jsonl" choice:"csv" default:"text" description:"Output format"`
+}
+
+func (o FormatOption) format() commands.Format {
+	f, _ := commands.ParseFormat(o.Format)
+	return f
+}
+
 type Add struct {
 	Name       string   `short:"n" long:"name" description:"Feed name"`
 	Tags       []string `short:"t" long:"tag" description:"Tag to apply to feed (may be specified multiple times)"`
+	NoCheck    bool     `long:"no-check" description:"Add the url as given without looking for or checking the feed"`
 	Positional struct {
 		Url  string `positional-arg-name:"URL" required:"yes"`
 		Name string `positional-arg-name:"NAME" required:"no"`
@@ -44,20 +55,25 @@ func (r *Add) Execute(args []string) error {
 	if name == "" {
 		name = r.Positional.Name
 	}
-	return cmds.Add(r.Positional.Url, name, r.Tags)
+	return cmds.Add(r.Positional.Url, name, r.Tags, r.NoCheck)
 }
 
-type Config struct{}
+type Config struct {
+	FormatOption
+}
 
 func (r *Config) Execute(args []string) error {
 	cmds, err := getCmds()
 	if err != nil {
 		return err
 	}
-	return cmds.ShowConfig()
+	return cmds.ShowConfig(r.format())
 }
 
-type List struct{}
+type List struct {
+	FormatOption
+	Search string `long:"search" short:"s" description:"Only list items matching the saved search with this name"`
+}
 
 func (r *List) Execute(args []string) error {
 	cmds, err := getCmds()
@@ -65,7 +81,22 @@ func (r *List) Execute(args []string) error {
 		return err
 	}
 
-	return cmds.List()
+	return cmds.List(r.format(), r.Search)
+}
+
+type Search struct {
+	Positional struct {
+		Query []string `positional-arg-name:"QUERY" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func (r *Search) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.Search(strings.Join(r.Positional.Query, " "))
 }
 
 type Version struct{}
@@ -80,26 +111,28 @@ func (r *Version) Execute(args []string) error {
 	return nil
 }
 
-type Refresh struct{}
+type Refresh struct {
+	FormatOption
+}
 
 func (r *Refresh) Execute(args []string) error {
 	cmds, err := getCmds()
 	if err != nil {
 		return err
 	}
-	return cmds.Refresh()
+	return cmds.Refresh(r.format())
 }
 
-type Unread struct{}
+type Unread struct {
+	FormatOption
+}
 
 func (r *Unread) Execute(args []string) error {
 	cmds, err := getCmds()
 	if err != nil {
 		return err
 	}
-	count := cmds.CountUnread()
-	fmt.Printf("%d\n", count)
-	return nil
+	return cmds.PrintUnread(r.format())
 }
 
 type Import struct {
@@ -117,6 +150,199 @@ func (r *Import) Execute(args []string) error {
 	return cmds.ImportFeeds(r.Positional.Source)
 }
 
+type Export struct {
+	Output   string `short:"o" long:"output" description:"File to write to instead of stdout"`
+	Backends bool   `short:"b" long:"backends" description:"Include feeds from Miniflux/FreshRSS backends"`
+}
+
+func (r *Export) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.ExportFeeds(r.Output, r.Backends)
+}
+
+type Feeds struct{}
+
+type FeedsList struct{}
+
+func (r *FeedsList) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.ListFeeds()
+}
+
+type Rules struct{}
+
+type RulesTest struct {
+	Apply bool `long:"apply" description:"Run the actions of matching rules, including commands"`
+}
+
+func (r *RulesTest) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.TestRules(r.Apply)
+}
+
+type Notifications struct{}
+
+type NotificationsTest struct {
+	Positional struct {
+		Name string `positional-arg-name:"NAME" description:"Only test the notification with this name"`
+	} `positional-args:"yes"`
+}
+
+func (r *NotificationsTest) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.TestNotifications(r.Positional.Name)
+}
+
+type FeedsHealth struct {
+	Threshold  int `short:"t" long:"threshold" description:"Failures in a row before a feed is listed, defaults to failurethreshold in config"`
+	Positional struct {
+		Feed string `positional-arg-name:"FEED" description:"Feed URL or name to show recent fetches for"`
+	} `positional-args:"yes"`
+}
+
+func (r *FeedsHealth) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.FeedsHealth(r.Positional.Feed, r.Threshold)
+}
+
+type FeedsRemove struct {
+	Positional struct {
+		Feed string `positional-arg-name:"FEED" required:"yes" description:"Feed URL or name"`
+	} `positional-args:"yes"`
+}
+
+func (r *FeedsRemove) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.RemoveFeed(r.Positional.Feed)
+}
+
+type FeedsRename struct {
+	Positional struct {
+		Feed string `positional-arg-name:"FEED" required:"yes" description:"Feed URL or name"`
+		Name string `positional-arg-name:"NAME" description:"New name, leave out to remove the name"`
+	} `positional-args:"yes"`
+}
+
+func (r *FeedsRename) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.RenameFeed(r.Positional.Feed, r.Positional.Name)
+}
+
+type FeedsTag struct {
+	Positional struct {
+		Feed string   `positional-arg-name:"FEED" required:"yes" description:"Feed URL or name"`
+		Tags []string `positional-arg-name:"TAG" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func (r *FeedsTag) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.TagFeed(r.Positional.Feed, r.Positional.Tags)
+}
+
+type FeedsUntag struct {
+	Positional struct {
+		Feed string   `positional-arg-name:"FEED" required:"yes" description:"Feed URL or name"`
+		Tags []string `positional-arg-name:"TAG" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func (r *FeedsUntag) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.UntagFeed(r.Positional.Feed, r.Positional.Tags)
+}
+
+type Serve struct {
+	Addr  string `long:"addr" default:"127.0.0.1:8420" description:"Address to listen on"`
+	Token string `long:"token" env:"NOM_TOKEN" description:"Bearer token required on every request"`
+	Fever bool   `long:"fever" description:"Serve the Fever API under /fever/ for mobile clients"`
+}
+
+func (r *Serve) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.Serve(commands.ServeOptions{Addr: r.Addr, Token: r.Token, Fever: r.Fever})
+}
+
+type Prune struct {
+	DryRun bool `long:"dry-run" description:"Report what would be deleted without deleting anything"`
+}
+
+func (r *Prune) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.Prune(r.DryRun)
+}
+
+type Download struct {
+	Dir        string `short:"d" long:"dir" default:"." description:"Directory to save enclosures to"`
+	Positional struct {
+		ID int `positional-arg-name:"ID" required:"yes" description:"ID of the item, as shown by list --format json"`
+	} `positional-args:"yes"`
+}
+
+func (r *Download) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.Download(r.Positional.ID, r.Dir)
+}
+
+type Daemon struct{}
+
+func (r *Daemon) Execute(args []string) error {
+	cmds, err := getCmds()
+	if err != nil {
+		return err
+	}
+
+	return cmds.Daemon()
+}
+
 func getCmds() (*commands.Commands, error) {
 	cfg, err := config.New(options.ConfigPath, options.Pager, options.PreviewFeeds, version)
 	if err != nil {
@@ -155,6 +381,23 @@ func main() {
 	parser.AddCommand("refresh", "Refresh feeds", "refresh feed(s) without opening TUI", &Refresh{})
 	parser.AddCommand("unread", "Count unread", "Get count of unread items", &Unread{})
 	parser.AddCommand("import", "Import feeds", "Import feeds from an OMPL file", &Import{})
+	feedsCmd, _ := parser.AddCommand("feeds", "Manage feeds", "List, remove, rename and tag feeds in the config file", &Feeds{})
+	feedsCmd.AddCommand("ls", "List feeds", "List feeds with item counts and last fetch status", &FeedsList{})
+	feedsCmd.AddCommand("health", "Show failing feeds", "List feeds failing repeatedly, or the fetch log for one feed", &FeedsHealth{})
+	feedsCmd.AddCommand("rm", "Remove feed", "Remove a feed and its stored items, apart from favourites", &FeedsRemove{})
+	feedsCmd.AddCommand("rename", "Rename feed", "Set the name of a feed", &FeedsRename{})
+	feedsCmd.AddCommand("tag", "Tag feed", "Add tags to a feed", &FeedsTag{})
+	feedsCmd.AddCommand("untag", "Untag feed", "Remove tags from a feed", &FeedsUntag{})
+	rulesCmd, _ := parser.AddCommand("rules", "Manage rules", "Check the rules in the config file", &Rules{})
+	rulesCmd.AddCommand("test", "Test rules", "Replay rules against stored items, listing what they match", &RulesTest{})
+	notificationsCmd, _ := parser.AddCommand("notifications", "Manage notifications", "Check the notifications in the config file", &Notifications{})
+	notificationsCmd.AddCommand("test", "Test notifications", "Send a test message to each notification", &NotificationsTest{})
+	parser.AddCommand("export", "Export feeds", "Export feeds to an OPML file", &Export{})
+	parser.AddCommand("prune", "Prune items", "Delete items according to the retention policy", &Prune{})
+	parser.AddCommand("daemon", "Run in the background", "Refresh feeds as they fall due without the TUI, until stopped with SIGTERM. SIGHUP reloads the config", &Daemon{})
+	parser.AddCommand("serve", "Serve API", "Serve a JSON API over the store for other clients", &Serve{})
+	parser.AddCommand("download", "Download enclosures", "Save the podcast or video enclosures of an item, resuming partial downloads", &Download{})
+	parser.AddCommand("search", "Search articles", "Full text search over stored article titles, content and authors", &Search{})
 
 	// parse the command line arguments
 	_, err := parser.Parse()
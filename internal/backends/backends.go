@@ -0,0 +1,29 @@
+package backends
+
+import "time"
+
+// Entry is an article as seen by a sync backend
+type Entry struct {
+	RemoteID    string
+	FeedURL     string
+	Link        string
+	GUID        string
+	Title       string
+	Content     string
+	Author      string
+	PublishedAt time.Time
+	Read        bool
+	Starred     bool
+}
+
+// Syncer is implemented by backends that nom can exchange entries and
+// read/starred state with
+type Syncer interface {
+	// ID matches config.Feed.Backend for the backend's feeds
+	ID() string
+	// Entries returns every unread or starred entry. Entries the backend
+	// knows about that aren't returned are treated as read and not starred.
+	Entries() ([]Entry, error)
+	SetRead(remoteIDs []string, read bool) error
+	SetStarred(remoteIDs []string, starred bool) error
+}
@@ -0,0 +1,109 @@
+package backends
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/greader"
+)
+
+// streamPageSize is the number of items requested per API call
+const streamPageSize = 1000
+
+// GReader syncs with any server implementing the Google Reader API
+type GReader struct {
+	id     string
+	client *greader.Client
+}
+
+func NewGReader(b config.GReaderBackend) *GReader {
+	return &GReader{
+		id:     b.ID(),
+		client: b.Client(),
+	}
+}
+
+func (g *GReader) ID() string {
+	return g.id
+}
+
+func (g *GReader) Entries() ([]Entry, error) {
+	subs, err := g.client.SubscriptionList()
+	if err != nil {
+		return nil, fmt.Errorf("GReader.Entries: %w", err)
+	}
+
+	// items only reference their feed by stream id
+	feedURLs := map[string]string{}
+	for _, s := range subs {
+		feedURLs[s.ID] = s.URL
+	}
+
+	unread, err := g.client.StreamContents(greader.StreamReadingList, greader.StreamOptions{Exclude: greader.StateRead, Count: streamPageSize})
+	if err != nil {
+		return nil, fmt.Errorf("GReader.Entries: %w", err)
+	}
+
+	starred, err := g.client.StreamContents(greader.StateStarred, greader.StreamOptions{Count: streamPageSize})
+	if err != nil {
+		return nil, fmt.Errorf("GReader.Entries: %w", err)
+	}
+
+	seen := map[string]bool{}
+	var ret []Entry
+	for _, i := range append(unread, starred...) {
+		if seen[i.ID] {
+			continue
+		}
+		seen[i.ID] = true
+
+		content := i.Content.Content
+		if content == "" {
+			content = i.Summary.Content
+		}
+
+		ret = append(ret, Entry{
+			RemoteID:    i.ID,
+			FeedURL:     feedURLs[i.Origin.StreamID],
+			Link:        i.Link(),
+			GUID:        i.ID,
+			Title:       i.Title,
+			Content:     content,
+			Author:      i.Author,
+			PublishedAt: time.Unix(i.Published, 0),
+			Read:        i.HasState(greader.StateRead),
+			Starred:     i.HasState(greader.StateStarred),
+		})
+	}
+
+	return ret, nil
+}
+
+func (g *GReader) SetRead(remoteIDs []string, read bool) error {
+	var err error
+	if read {
+		err = g.client.EditTag(remoteIDs, greader.StateRead, "")
+	} else {
+		err = g.client.EditTag(remoteIDs, "", greader.StateRead)
+	}
+	if err != nil {
+		return fmt.Errorf("GReader.SetRead: %w", err)
+	}
+
+	return nil
+}
+
+func (g *GReader) SetStarred(remoteIDs []string, starred bool) error {
+	var err error
+	if starred {
+		err = g.client.EditTag(remoteIDs, greader.StateStarred, "")
+	} else {
+		err = g.client.EditTag(remoteIDs, "", greader.StateStarred)
+	}
+	if err != nil {
+		return fmt.Errorf("GReader.SetStarred: %w", err)
+	}
+
+	return nil
+}
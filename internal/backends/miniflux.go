@@ -0,0 +1,147 @@
+package backends
+
+import (
+	"fmt"
+	"strconv"
+
+	miniflux "miniflux.app/v2/client"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+)
+
+// entriesPageSize is the number of entries requested per API call
+const entriesPageSize = 250
+
+type Miniflux struct {
+	id     string
+	client *miniflux.Client
+}
+
+func NewMiniflux(b config.MinifluxBackend) *Miniflux {
+	return &Miniflux{
+		id:     b.ID(),
+		client: miniflux.NewClient(b.Host, b.APIKey),
+	}
+}
+
+func (m *Miniflux) ID() string {
+	return m.id
+}
+
+func (m *Miniflux) Entries() ([]Entry, error) {
+	unread, err := m.entries(&miniflux.Filter{Status: miniflux.EntryStatusUnread})
+	if err != nil {
+		return nil, fmt.Errorf("Miniflux.Entries: %w", err)
+	}
+
+	starred, err := m.entries(&miniflux.Filter{Starred: miniflux.FilterOnlyStarred})
+	if err != nil {
+		return nil, fmt.Errorf("Miniflux.Entries: %w", err)
+	}
+
+	seen := map[int64]bool{}
+	var ret []Entry
+	for _, e := range append(unread, starred...) {
+		if seen[e.ID] {
+			continue
+		}
+		seen[e.ID] = true
+
+		entry := Entry{
+			RemoteID:    strconv.FormatInt(e.ID, 10),
+			Link:        e.URL,
+			GUID:        e.Hash,
+			Title:       e.Title,
+			Content:     e.Content,
+			Author:      e.Author,
+			PublishedAt: e.Date,
+			Read:        e.Status == miniflux.EntryStatusRead,
+			Starred:     e.Starred,
+		}
+		if e.Feed != nil {
+			entry.FeedURL = e.Feed.FeedURL
+		}
+
+		ret = append(ret, entry)
+	}
+
+	return ret, nil
+}
+
+// entries pages through all entries matching filter
+func (m *Miniflux) entries(filter *miniflux.Filter) (miniflux.Entries, error) {
+	var ret miniflux.Entries
+
+	filter.Limit = entriesPageSize
+	for {
+		res, err := m.client.Entries(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, res.Entries...)
+		filter.Offset += len(res.Entries)
+
+		if len(res.Entries) < entriesPageSize || filter.Offset >= res.Total {
+			return ret, nil
+		}
+	}
+}
+
+func (m *Miniflux) SetRead(remoteIDs []string, read bool) error {
+	ids, err := parseIDs(remoteIDs)
+	if err != nil {
+		return fmt.Errorf("Miniflux.SetRead: %w", err)
+	}
+
+	status := miniflux.EntryStatusUnread
+	if read {
+		status = miniflux.EntryStatusRead
+	}
+
+	err = m.client.UpdateEntries(ids, status)
+	if err != nil {
+		return fmt.Errorf("Miniflux.SetRead: %w", err)
+	}
+
+	return nil
+}
+
+// SetStarred has to check each entry first, the API can only toggle stars
+func (m *Miniflux) SetStarred(remoteIDs []string, starred bool) error {
+	ids, err := parseIDs(remoteIDs)
+	if err != nil {
+		return fmt.Errorf("Miniflux.SetStarred: %w", err)
+	}
+
+	for _, id := range ids {
+		e, err := m.client.Entry(id)
+		if err != nil {
+			return fmt.Errorf("Miniflux.SetStarred: %w", err)
+		}
+
+		if e.Starred == starred {
+			continue
+		}
+
+		err = m.client.ToggleStarred(id)
+		if err != nil {
+			return fmt.Errorf("Miniflux.SetStarred: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func parseIDs(remoteIDs []string) ([]int64, error) {
+	ids := make([]int64, len(remoteIDs))
+	for i, r := range remoteIDs {
+		id, err := strconv.ParseInt(r, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry id %q", r)
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -29,10 +30,13 @@ import (
 type Commands struct {
 	config *config.Config
 	store  store.Store
+	// refreshMu stops refreshes from the TUI, Monitor and the server
+	// overlapping
+	refreshMu *sync.Mutex
 }
 
 func New(config *config.Config, store store.Store) *Commands {
-	return &Commands{config, store}
+	return &Commands{config, store, &sync.Mutex{}}
 }
 
 func convertItems(its []store.Item) []list.Item {
@@ -71,12 +75,53 @@ func GetWslHostName() string {
 	return strings.TrimSpace(string(out))
 }
 
-func (c Commands) List() error {
-	its, err := c.GetAllFeeds()
+// List prints the items, narrowed down to a saved search if search is set
+func (c Commands) List(format Format, search string) error {
+	its, err := c.GetItems(search)
 	if err != nil {
 		return fmt.Errorf("commands List: %w", err)
 	}
 
+	if format != FormatText {
+		return writeItems(os.Stdout, format, its)
+	}
+
+	return c.printItems(its)
+}
+
+// Search prints stored items whose title, content or author match query
+func (c Commands) Search(query string) error {
+	its, err := c.search(query)
+	if err != nil {
+		return fmt.Errorf("commands Search: %w", err)
+	}
+
+	if len(its) == 0 {
+		fmt.Println("no matching items")
+		return nil
+	}
+
+	return c.printItems(its)
+}
+
+// search runs query as a full text query, or as a plain phrase if it isn't
+// valid query syntax, e.g. a hyphenated word or a stray quote
+func (c Commands) search(query string) ([]store.Item, error) {
+	its, err := c.store.Search(query)
+	if err != nil {
+		its, err = c.store.Search(store.QuoteSearchTerm(query))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	its = withoutHidden(its)
+	c.addFeedInfo(its)
+
+	return its, nil
+}
+
+func (c Commands) printItems(its []store.Item) error {
 	output := ""
 
 	for _, item := range its {
@@ -91,26 +136,71 @@ func (c Commands) List() error {
 	return outputToPager(output)
 }
 
-func (c Commands) Add(url string, name string, tags []string) error {
-	err := c.config.AddFeed(config.Feed{URL: url, Name: name, Tags: tags})
+// Add saves a feed to the config. Website URLs are searched for the feeds
+// they advertise, and the feed has to parse before it's saved.
+func (c Commands) Add(url string, name string, tags []string, noCheck bool) error {
+	return c.add(url, name, tags, noCheck, promptForFeed)
+}
+
+func (c Commands) add(url string, name string, tags []string, noCheck bool, choose feedChooser) error {
+	feed := rss.Candidate{URL: url}
+	if !noCheck {
+		// discovery only has the global http options, so a feed that needs
+		// its own auth or proxy is still added as given
+		candidates, err := rss.Discover(url, c.config.HTTPOptions, c.config.Version)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: couldn't check %s, adding it as given: %s\n", url, err)
+		} else {
+			feed = candidates[0]
+			if len(candidates) > 1 {
+				feed, err = choose(candidates)
+				if err != nil {
+					return fmt.Errorf("commands Add: %w", err)
+				}
+			}
+		}
+	}
+
+	if name == "" {
+		name = strings.TrimSpace(feed.Title)
+	}
+
+	err := c.config.AddFeed(config.Feed{URL: feed.URL, Name: name, Tags: tags})
 	if err != nil {
 		return fmt.Errorf("commands Add: %w", err)
 	}
 
+	if feed.URL != url {
+		fmt.Printf("Added %s\n", feed.URL)
+	}
+
 	return nil
 }
 
-func (c Commands) Refresh() error {
-	_, _, err := c.fetchAllFeeds()
+func (c Commands) Refresh(format Format) error {
+	its, errorItems, err := c.fetchAllFeeds()
 	if err != nil {
 		return fmt.Errorf("commands Refresh: %w", err)
 	}
 
+	if format != FormatText {
+		return writeRefresh(os.Stdout, format, its, errorItems)
+	}
+
+	for _, e := range errorItems {
+		fmt.Fprintf(os.Stderr, "Error fetching %s: %s\n", e.FeedURL, e.Err)
+	}
+
 	return nil
 }
 
-func (c Commands) ShowConfig() error {
-	yaml, err := yaml.Marshal(&c.config)
+func (c Commands) ShowConfig(format Format) error {
+	cfg := c.config.Redacted()
+	if format != FormatText {
+		return writeConfig(os.Stdout, format, cfg)
+	}
+
+	yaml, err := yaml.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("commands Config: %w", err)
 	}
@@ -146,10 +236,13 @@ func (c Commands) ImportFeeds(source string) error {
 	for _, outline := range opml.Body.Outlines {
 		if outline.XMLUrl == nil {
 			log.Printf("config.ImportFeeds: No url for outline %s\n", outline.Title)
+		} else if !importableURL(outline.XMLUrl) {
+			log.Printf("config.ImportFeeds: skipping %s, only http(s) feeds can be imported\n", outline.XMLUrl)
 		} else {
 			feeds = append(feeds, config.Feed{
 				Name: outline.Title,
 				URL:  outline.XMLUrl.String(),
+				Tags: outline.Tags(),
 			})
 		}
 
@@ -170,15 +263,25 @@ func (c Commands) ImportFeeds(source string) error {
 	return nil
 }
 
+// importableURL reports whether an OPML feed may be added. exec: and file://
+// sources run commands and read local files, so a shared OPML file must not
+// be able to add them.
+func importableURL(u *url.URL) bool {
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
 func getChildFeeds(outline Outline) []config.Feed {
 	feeds := make([]config.Feed, 0)
 	for _, child := range outline.Outlines {
 		if child.XMLUrl == nil {
 			log.Printf("getChildFeeds: No url for outline %s\n", child.Title)
+		} else if !importableURL(child.XMLUrl) {
+			log.Printf("getChildFeeds: skipping %s, only http(s) feeds can be imported\n", child.XMLUrl)
 		} else {
 			feeds = append(feeds, config.Feed{
 				Name: child.Title,
 				URL:  child.XMLUrl.String(),
+				Tags: child.Tags(),
 			})
 		}
 
@@ -189,9 +292,11 @@ func getChildFeeds(outline Outline) []config.Feed {
 }
 
 type FetchResultError struct {
-	res rss.RSS
-	err error
-	url string
+	res       rss.RSS
+	err       error
+	url       string
+	fetchedAt time.Time
+	duration  time.Duration
 }
 
 type ErrorItem struct {
@@ -200,44 +305,99 @@ type ErrorItem struct {
 }
 
 func (c Commands) fetchAllFeeds() ([]store.Item, []ErrorItem, error) {
+	feeds := c.config.GetFeeds()
+
+	if len(feeds) <= 0 {
+		return nil, nil, fmt.Errorf("no feeds found, add to nom/config.yml")
+	}
+
+	// a running daemon keeps feeds fresh and syncs backends, so just fetch
+	// the feeds it hasn't got to yet
+	if c.daemonRunning() {
+		due, err := c.dueFeeds(time.Now())
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetchAllFeeds: %w", err)
+		}
+		return c.fetchFeeds(due, false)
+	}
+
+	return c.fetchFeeds(feeds, true)
+}
+
+// fetchFeeds fetches feeds and stores their items, syncing backends as well
+// if sync is set
+func (c Commands) fetchFeeds(feeds []config.Feed, sync bool) ([]store.Item, []ErrorItem, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
 	var (
 		items      []store.Item
-		wg         sync.WaitGroup
 		errorItems []ErrorItem
+		newItems   []store.Item
 	)
 
-	feeds := c.config.GetFeeds()
-
-	if len(feeds) <= 0 {
-		return items, errorItems, fmt.Errorf("no feeds found, add to nom/config.yml")
+	// read cache headers up front rather than from each goroutine
+	metas, err := c.store.GetAllFeedMeta()
+	if err != nil {
+		return items, errorItems, fmt.Errorf("fetchFeeds: %w", err)
 	}
 
-	ch := make(chan FetchResultError)
+	// feeds from syncing backends come through syncBackends instead
+	synced := c.syncedBackends()
 
+	var jobs []fetchJob
 	for _, feed := range feeds {
-		wg.Add(1)
+		if synced[feed.Backend] {
+			continue
+		}
 
-		go fetchFeed(ch, &wg, feed, c.config.HTTPOptions, c.config.Version)
+		meta := metas[feed.URL]
+		cache := rss.CacheHeaders{ETag: meta.ETag, LastModified: meta.LastModified}
+		jobs = append(jobs, fetchJob{feed: feed, cache: cache})
 	}
 
-	go func() {
-		wg.Wait()
-		close(ch)
-	}()
+	// results are gathered before the batch starts so that the write lock
+	// isn't held while waiting on the network, as the daemon or another nom
+	// may be using the database too
+	var results []FetchResultError
+	for r := range c.newFetcher().run(jobs) {
+		results = append(results, r)
+	}
 
-	err := c.store.BeginBatch()
+	err = c.store.BeginBatch()
 	if err != nil {
-		return items, errorItems, fmt.Errorf("fetchAllFeeds: failed to begin batch: %w", err)
+		return items, errorItems, fmt.Errorf("fetchFeeds: failed to begin batch: %w", err)
 	}
-	defer c.store.EndBatch()
 
-	for result := range ch {
+	for _, result := range results {
+		entry := store.FetchLog{
+			FeedURL:   result.url,
+			FetchedAt: result.fetchedAt,
+			Status:    result.res.StatusCode,
+			Duration:  result.duration,
+		}
+
+		// nothing new since the last fetch, which is not an error
+		if errors.Is(result.err, rss.ErrNotModified) {
+			c.recordFetch(entry)
+			c.scheduleFeed(result.url, metas[result.url].UpdateHint, result.fetchedAt)
+			continue
+		}
+
 		if result.err != nil {
+			entry.Error = result.err.Error()
+			c.recordFetch(entry)
+			c.scheduleFeed(result.url, metas[result.url].UpdateHint, result.fetchedAt)
 			errorItems = append(errorItems, ErrorItem{FeedURL: result.url, Err: result.err})
 			continue
 		}
 
+		stored := true
 		for _, r := range result.res.Channel.Items {
+			if c.tooOldToKeep(r.PubDate) {
+				continue
+			}
+
 			i := store.Item{
 				Author:      r.Author,
 				Content:     r.Content,
@@ -247,50 +407,176 @@ func (c Commands) fetchAllFeeds() ([]store.Item, []ErrorItem, error) {
 				GUID:        r.GUID,
 				PublishedAt: r.PubDate,
 				Title:       r.Title,
+				Enclosures:  storeEnclosures(r.Enclosures),
+				Image:       r.Image,
 			}
 
-			err := c.store.UpsertItem(&i)
+			// items dropped by retention stay gone while the feed still lists them
+			pruned, err := c.store.WasPruned(&i)
 			if err != nil {
-				log.Printf("[commands.go] fetchAllFeeds: failed to upsert item: %v", err)
+				log.Printf("[commands.go] fetchFeeds: failed to check pruned items: %v", err)
+			}
+			if pruned {
+				continue
+			}
+
+			inserted, err := c.store.UpsertItem(&i)
+			if err != nil {
+				log.Printf("[commands.go] fetchFeeds: failed to upsert item: %v", err)
+				stored = false
 				continue
 			}
 
+			if inserted {
+				entry.NewItems++
+				newItems = append(newItems, i)
+			}
+
 			items = append(items, i)
 		}
+
+		// the cache headers are only kept once every item is stored, otherwise
+		// the next fetch would get a 304 and never retry the missing items
+		meta := store.FeedMeta{FeedURL: result.url, UpdateHint: result.res.UpdateHint}
+		if stored {
+			meta.ETag = result.res.Cache.ETag
+			meta.LastModified = result.res.Cache.LastModified
+		}
+		err := c.store.UpsertFeedMeta(meta)
+		if err != nil {
+			log.Printf("[commands.go] fetchFeeds: failed to save cache headers: %v", err)
+		}
+
+		c.recordFetch(entry)
+		c.scheduleFeed(result.url, result.res.UpdateHint, result.fetchedAt)
+	}
+
+	err = c.store.EndBatch()
+	if err != nil {
+		return items, errorItems, fmt.Errorf("fetchFeeds: %w", err)
+	}
+
+	if sync {
+		syncedItems, syncedNew, syncErrors := c.syncBackends()
+		items = append(items, syncedItems...)
+		newItems = append(newItems, syncedNew...)
+		errorItems = append(errorItems, syncErrors...)
+	}
+
+	// rules run once the batch is committed, as their commands can be slow
+	newItems, err = c.applyRules(newItems)
+	if err != nil {
+		log.Printf("[commands.go] fetchFeeds: %v", err)
+	}
+	c.fetchFullTexts(newItems)
+	c.notify(newItems)
+	updated := map[int]store.Item{}
+	for _, it := range newItems {
+		updated[it.ID] = it
+	}
+	for i := range items {
+		if it, ok := updated[items[i].ID]; ok {
+			items[i] = it
+		}
+	}
+
+	_, err = c.applyRetention(false)
+	if err != nil {
+		log.Printf("[commands.go] fetchFeeds: failed to apply retention: %v", err)
 	}
 
 	return items, errorItems, nil
 }
 
 func (c Commands) Monitor(prog *tea.Program) {
-	if c.config.RefreshInterval == 0 {
+	reload := func(status string) {
+		items, err := c.GetAllFeeds()
+		if err != nil {
+			log.Println("Refresh failed: ", err)
+			prog.Send(statusUpdate{
+				status: "Refresh failed",
+			})
+		}
+		prog.Send(listUpdate{
+			items:  convertItems(items),
+			status: status,
+		})
+	}
+
+	go c.refreshEvery(nil, func(_ []store.Item, _ []ErrorItem, err error) {
+		// errors are recorded in the fetch log, there's no room to show them
+		// all in the TUI
+		if err != nil {
+			log.Println("Refresh failed: ", err)
+			prog.Send(statusUpdate{
+				status: "Refresh failed",
+			})
+			return
+		}
+
+		reload("Refreshed.")
+	})
+
+	go c.watchDaemon(nil, func() {
+		reload("Refreshed by daemon.")
+	})
+}
+
+// refreshEvery fetches feeds as they fall due, passing the results to
+// onRefresh, until done is closed. Backends are synced every RefreshInterval
+// minutes. Nothing is fetched while a daemon is running, and it returns
+// straight away if neither RefreshInterval nor any feed's interval is set.
+func (c Commands) refreshEvery(done <-chan struct{}, onRefresh func([]store.Item, []ErrorItem, error)) {
+	if c.config.RefreshInterval == 0 && !c.hasFeedIntervals() {
 		return
 	}
 
-	go func() {
-		t := time.NewTicker(time.Duration(c.config.RefreshInterval) * time.Minute)
-		for range t.C {
-			err := c.Refresh()
-			if err != nil {
-				log.Println("Refresh failed: ", err)
-				prog.Send(statusUpdate{
-					status: "Refresh failed",
-				})
-			} else {
-				items, err := c.GetAllFeeds()
-				if err != nil {
-					log.Println("Refresh failed: ", err)
-					prog.Send(statusUpdate{
-						status: "Refresh failed",
-					})
-				}
-				prog.Send(listUpdate{
-					items:  convertItems(items),
-					status: "Refreshed.",
-				})
+	t := time.NewTicker(scheduleTick)
+	defer t.Stop()
+
+	nextSync := time.Now().Add(c.refreshInterval())
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-t.C:
+			// leave fetching to the daemon while it's running
+			if c.daemonRunning() {
+				continue
+			}
+
+			sync := c.config.RefreshInterval > 0 && !now.Before(nextSync)
+			if c.refreshDue(now, sync, onRefresh) && sync {
+				nextSync = now.Add(c.refreshInterval())
 			}
 		}
-	}()
+	}
+}
+
+// refreshDue fetches the feeds due at now, syncing backends as well if sync
+// is set, and passes the results to onRefresh. It reports whether there was
+// anything to do.
+func (c Commands) refreshDue(now time.Time, sync bool, onRefresh func([]store.Item, []ErrorItem, error)) bool {
+	due, err := c.dueFeeds(now)
+	if err != nil {
+		onRefresh(nil, nil, err)
+		return false
+	}
+
+	// without a RefreshInterval only feeds with their own interval are
+	// refreshed in the background
+	if c.config.RefreshInterval == 0 {
+		due = slices.DeleteFunc(due, func(f config.Feed) bool {
+			return f.IntervalDuration() == 0
+		})
+	}
+
+	if len(due) == 0 && !sync {
+		return false
+	}
+
+	onRefresh(c.fetchFeeds(due, sync))
+	return true
 }
 
 func (c Commands) CountUnread() int {
@@ -308,7 +594,7 @@ func (c Commands) GetGlamourisedArticle(ID int) (string, error) {
 	}
 
 	if c.config.AutoRead && !article.Read() {
-		err = c.store.ToggleRead(article.ID)
+		err = c.store.SetRead(article.ID, true)
 		if err != nil {
 			return "", fmt.Errorf("[commands.go] GetGlamourisedArticle: %w", err)
 		}
@@ -319,6 +605,14 @@ func (c Commands) GetGlamourisedArticle(ID int) (string, error) {
 		return "", fmt.Errorf("[commands.go] GetGlamourisedArticle: %w", err)
 	}
 
+	// a read article that changed has now been seen again
+	if article.Read() && article.Updated() {
+		err = c.store.ClearRevised(article.ID)
+		if err != nil {
+			return "", fmt.Errorf("[commands.go] GetGlamourisedArticle: %w", err)
+		}
+	}
+
 	return content, nil
 }
 
@@ -352,6 +646,10 @@ func glamouriseItem(item store.Item, theme config.Theme) (string, error) {
 		title = fmt.Sprintf("%s - %s", item.Title, theme.ReadIcon)
 	}
 
+	if item.Updated() {
+		title = fmt.Sprintf("%s %s", title, theme.UpdatedIcon)
+	}
+
 	mdown += "# " + title
 	mdown += "\n"
 	mdown += item.Author
@@ -359,10 +657,22 @@ func glamouriseItem(item store.Item, theme config.Theme) (string, error) {
 		mdown += "\n"
 		mdown += item.PublishedAt.String()
 	}
+	if !item.RevisedAt.IsZero() {
+		mdown += "\n"
+		mdown += "Updated " + item.RevisedAt.String()
+	}
 	mdown += "\n\n"
 	mdown += item.Link
 	mdown += "\n\n"
-	mdown += htmlToMd(item.Content)
+	if len(item.Enclosures) > 0 || item.Image != "" {
+		mdown += enclosuresMd(item)
+		mdown += "\n\n"
+	}
+	content := item.Content
+	if item.FullText != "" {
+		content = item.FullText
+	}
+	mdown += htmlToMd(content)
 
 	r, _ := glamour.NewTermRenderer(
 		glamour.WithStyles(getStyleConfigWithOverrides(theme)),
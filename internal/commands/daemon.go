@@ -0,0 +1,212 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+// daemonRefreshInterval is used by the daemon when refreshinterval isn't
+// set, as it would have nothing to do otherwise
+const daemonRefreshInterval = 15
+
+var ErrDaemonRunning = errors.New("commands.Daemon: a daemon is already running")
+
+// Daemon refreshes feeds as they fall due until it gets SIGTERM or an
+// interrupt, writing its PID to the PID file so that the TUI and other
+// commands leave fetching to it. SIGHUP reloads the config file.
+func (c Commands) Daemon() error {
+	pidFile := c.config.DaemonPIDFile()
+	err := writePIDFile(pidFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(pidFile)
+
+	logFile, err := os.OpenFile(c.config.DaemonLogFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("commands Daemon: %w", err)
+	}
+	defer logFile.Close()
+	log.SetOutput(logFile)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(sigs)
+
+	log.Printf("[daemon.go] started with pid %d", os.Getpid())
+
+	cmds := c
+	for {
+		if cmds.config.RefreshInterval == 0 {
+			cmds.config.RefreshInterval = daemonRefreshInterval
+		}
+
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			cmds.refreshDue(time.Now(), true, logRefresh)
+			cmds.refreshEvery(done, logRefresh)
+		}()
+
+		sig := <-sigs
+		log.Printf("[daemon.go] got %s", sig)
+
+		// let a fetch that's under way finish and commit
+		close(done)
+		<-stopped
+
+		if sig != syscall.SIGHUP {
+			log.Printf("[daemon.go] stopped")
+			return nil
+		}
+
+		next, err := cmds.reload()
+		if err != nil {
+			log.Printf("[daemon.go] keeping the old config: %v", err)
+			continue
+		}
+		cmds = next
+		log.Printf("[daemon.go] reloaded %s", cmds.config.ConfigPath)
+	}
+}
+
+// reload reads the config file again. The store is kept, so a change of
+// database needs a restart.
+func (c Commands) reload() (Commands, error) {
+	cfg, err := config.New(c.config.ConfigPath, c.config.Pager, []string{}, c.config.Version)
+	if err != nil {
+		return c, fmt.Errorf("reload: %w", err)
+	}
+
+	if err = cfg.Load(); err != nil {
+		return c, fmt.Errorf("reload: %w", err)
+	}
+
+	// secrets may have been rotated along with the config
+	config.ClearSecretCache()
+
+	return *New(cfg, c.store), nil
+}
+
+func logRefresh(items []store.Item, errorItems []ErrorItem, err error) {
+	if err != nil {
+		log.Printf("[daemon.go] refresh failed: %v", err)
+		return
+	}
+
+	for _, e := range errorItems {
+		log.Printf("[daemon.go] error fetching %s: %v", e.FeedURL, e.Err)
+	}
+	log.Printf("[daemon.go] refreshed, %d items", len(items))
+}
+
+// daemonRunning reports whether a daemon other than this process is running
+// with the same config
+func (c Commands) daemonRunning() bool {
+	if c.config.IsPreviewMode() {
+		return false
+	}
+
+	pid, err := readPIDFile(c.config.DaemonPIDFile())
+	return err == nil && pid != os.Getpid() && processAlive(pid)
+}
+
+// watchDaemon calls onFetch whenever a running daemon has fetched feeds, so
+// that the TUI can show what it found, until done is closed
+func (c Commands) watchDaemon(done <-chan struct{}, onFetch func()) {
+	t := time.NewTicker(scheduleTick)
+	defer t.Stop()
+
+	last, _ := c.lastFetchedAt()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+			if !c.daemonRunning() {
+				continue
+			}
+
+			at, err := c.lastFetchedAt()
+			if err != nil {
+				log.Printf("[daemon.go] watchDaemon: %v", err)
+				continue
+			}
+
+			if at.After(last) {
+				last = at
+				onFetch()
+			}
+		}
+	}
+}
+
+// lastFetchedAt is when any feed was last fetched
+func (c Commands) lastFetchedAt() (time.Time, error) {
+	metas, err := c.store.GetAllFeedMeta()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("lastFetchedAt: %w", err)
+	}
+
+	var last time.Time
+	for _, m := range metas {
+		if m.LastFetchedAt.After(last) {
+			last = m.LastFetchedAt
+		}
+	}
+
+	return last, nil
+}
+
+// writePIDFile claims the PID file for this process, replacing it if the
+// process it names has gone
+func writePIDFile(path string) error {
+	if pid, err := readPIDFile(path); err == nil {
+		if processAlive(pid) {
+			return fmt.Errorf("%w with pid %d", ErrDaemonRunning, pid)
+		}
+		os.Remove(path)
+	}
+
+	// O_EXCL so that of two daemons started together only one wins
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return ErrDaemonRunning
+	}
+	if err != nil {
+		return fmt.Errorf("writePIDFile: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+	if err != nil {
+		return fmt.Errorf("writePIDFile: %w", err)
+	}
+
+	return nil
+}
+
+func readPIDFile(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("readPIDFile: bad pid in %s", path)
+	}
+
+	return pid, nil
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+func TestDaemonPIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nom.pid")
+
+	// a process that has exited leaves a stale PID file
+	done := exec.Command(os.Args[0], "-test.run=^$")
+	test.HandleError(t, done.Run())
+	test.HandleError(t, os.WriteFile(path, []byte(strconv.Itoa(done.Process.Pid)), 0644))
+
+	err := writePIDFile(path)
+	test.HandleError(t, err)
+	pid, err := readPIDFile(path)
+	test.HandleError(t, err)
+	test.Equal(t, os.Getpid(), pid, "stale PID file should be replaced")
+
+	err = writePIDFile(path)
+	test.Equal(t, true, errors.Is(err, ErrDaemonRunning), "expected a running daemon")
+}
+
+func TestFetchWithDaemon(t *testing.T) {
+	var mu sync.Mutex
+	fetches := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches[r.URL.Path]++
+		mu.Unlock()
+		fmt.Fprint(w, healthFeed)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		ConfigDir:       dir,
+		RefreshInterval: 60,
+		Feeds:           []config.Feed{{URL: srv.URL + "/a"}, {URL: srv.URL + "/b"}},
+	}
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+
+	_, _, err = c.fetchFeeds(cfg.Feeds[:1], false)
+	test.HandleError(t, err)
+
+	// the parent process stands in for the daemon
+	err = os.WriteFile(cfg.DaemonPIDFile(), []byte(strconv.Itoa(os.Getppid())), 0644)
+	test.HandleError(t, err)
+	test.Equal(t, true, c.daemonRunning(), "expected the daemon to be seen")
+
+	_, _, err = c.fetchAllFeeds()
+	test.HandleError(t, err)
+	test.Equal(t, 1, fetches["/a"], "feed fetched by the daemon shouldn't be fetched again")
+	test.Equal(t, 1, fetches["/b"], "feed not yet fetched should be")
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package commands
+
+import "golang.org/x/sys/windows"
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(h)
+
+	var code uint32
+	err = windows.GetExitCodeProcess(h, &code)
+	// STILL_ACTIVE
+	return err == nil && code == 259
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+func TestNormaliseLink(t *testing.T) {
+	for link, want := range map[string]string{
+		"https://www.example.com/post/":                        "example.com/post",
+		"http://example.com/post?utm_source=rss&utm_medium=x":  "example.com/post",
+		"https://example.com/post?id=2&utm_campaign=a#comment": "example.com/post?id=2",
+		"not a url/": "not a url",
+	} {
+		test.Equal(t, want, store.NormaliseLink(link), "wrong normalised link for "+link)
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	body := strings.Repeat("the quick brown fox jumps over the lazy dog again and again ", 4)
+	items := []store.Item{
+		{FeedURL: "https://a.com/feed", Link: "https://a.com/post/", Title: "Post"},
+		{FeedURL: "https://agg.com/feed", Link: "https://a.com/post?utm_source=agg", Title: "Post"},
+		{FeedURL: "https://b.com/feed", Link: "https://b.com/1", GUID: "urn:uuid:1234567890", Title: "Other"},
+		{FeedURL: "https://mirror.com/feed", Link: "https://mirror.com/1", GUID: "urn:uuid:1234567890", Title: "Other"},
+		{FeedURL: "https://c.com/feed", Link: "https://c.com/x", Title: "Fox news", Content: "<p>" + body + "</p>"},
+		{FeedURL: "https://d.com/feed", Link: "https://d.com/y", Title: "Fox news!", Content: body + " today"},
+		{FeedURL: "https://a.com/feed", Link: "https://a.com/unrelated", Title: "Unrelated", Content: strings.Repeat("lorem ipsum dolor sit amet ", 5)},
+	}
+	for i := range items {
+		_, err := s.UpsertItem(&items[i])
+		test.HandleError(t, err)
+	}
+
+	test.Equal(t, items[0].ID, items[1].DuplicateOf, "expected matching normalised links")
+	test.Equal(t, items[2].ID, items[3].DuplicateOf, "expected matching GUIDs")
+	test.Equal(t, items[4].ID, items[5].DuplicateOf, "expected similar content")
+	test.Equal(t, 0, items[6].DuplicateOf, "expected unrelated item to stand alone")
+
+	var feeds []config.Feed
+	for _, it := range items {
+		feeds = append(feeds, config.Feed{URL: it.FeedURL})
+	}
+	feeds[1].Name = "Aggregator"
+	c := New(&config.Config{Ordering: "asc", Feeds: feeds}, s)
+
+	its, err := c.GetAllFeeds()
+	test.HandleError(t, err)
+	test.Equal(t, 4, len(its), "expected duplicates to be collapsed")
+	test.Equal(t, "https://a.com/feed, Aggregator", strings.Join(its[0].Sources, ", "), "expected every source")
+	test.Equal(t, "https://a.com/feed, Aggregator: Post", ItemToTUIItem(its[0]).FeedName+": "+its[0].Title, "expected sources in the list")
+
+	// reading either copy reads both
+	test.HandleError(t, s.ToggleRead(items[1].ID))
+	original, err := s.GetItemByID(items[0].ID)
+	test.HandleError(t, err)
+	test.Equal(t, true, original.Read(), "expected original to be read with its copy")
+
+	test.HandleError(t, s.ToggleRead(items[0].ID))
+	dup, err := s.GetItemByID(items[1].ID)
+	test.HandleError(t, err)
+	test.Equal(t, false, dup.Read(), "expected copy to be unread with its original")
+}
+
+func TestMarkDuplicatesRead(t *testing.T) {
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	items := []store.Item{
+		{FeedURL: "https://a.com/feed", Link: "https://a.com/post", Title: "Post"},
+		{FeedURL: "https://agg.com/feed", Link: "https://a.com/post/", Title: "Post"},
+	}
+	for i := range items {
+		_, err := s.UpsertItem(&items[i])
+		test.HandleError(t, err)
+	}
+	test.Equal(t, items[0].ID, items[1].DuplicateOf, "expected a copy")
+	test.HandleError(t, s.SetRemoteID(items[1].ID, "miniflux", "42"))
+
+	c := New(&config.Config{Feeds: []config.Feed{{URL: items[0].FeedURL}, {URL: items[1].FeedURL}}}, s)
+
+	// marking every feed read reaches both copies, which mustn't toggle back
+	for range 2 {
+		test.HandleError(t, c.feverMark("group", "read", "0", ""))
+		for _, it := range items {
+			got, err := s.GetItemByID(it.ID)
+			test.HandleError(t, err)
+			test.Equal(t, true, got.Read(), "expected "+it.FeedURL+" to be read")
+		}
+	}
+
+	remote, err := s.GetRemoteItems("miniflux")
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(remote), "expected the remote item")
+	test.Equal(t, true, remote[0].ReadDirty, "expected the copy's read state to be synced")
+}
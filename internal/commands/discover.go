@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/guyfedwards/nom/v2/internal/rss"
+)
+
+// feedChooser picks one of several feeds found for a URL
+type feedChooser func(candidates []rss.Candidate) (rss.Candidate, error)
+
+// promptForFeed asks on the terminal which feed to add, taking the first when
+// nom isn't run interactively
+func promptForFeed(candidates []rss.Candidate) (rss.Candidate, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return candidates[0], nil
+	}
+
+	return chooseFeed(os.Stdin, os.Stdout, candidates)
+}
+
+func chooseFeed(in io.Reader, out io.Writer, candidates []rss.Candidate) (rss.Candidate, error) {
+	fmt.Fprintln(out, "Found several feeds:")
+	for i, c := range candidates {
+		title := c.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		fmt.Fprintf(out, "  %d. %s - %s\n", i+1, title, c.URL)
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "Choose a feed [1-%d] (default 1): ", len(candidates))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return rss.Candidate{}, fmt.Errorf("chooseFeed: %w", err)
+			}
+			return candidates[0], nil
+		}
+
+		answer := strings.TrimSpace(scanner.Text())
+		if answer == "" {
+			return candidates[0], nil
+		}
+
+		n, err := strconv.Atoi(answer)
+		if err == nil && n >= 1 && n <= len(candidates) {
+			return candidates[n-1], nil
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/rss"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+const discoverFeed = `<?xml version="1.0"?><rss version="2.0"><channel><title>%s</title></channel></rss>`
+
+func TestAddDiscoversFeeds(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/blog":
+			fmt.Fprint(w, `<html><head>
+<link rel="alternate" type="application/rss+xml" title="Posts" href="/posts.xml">
+<link rel="alternate" type="application/atom+xml" title="Comments" href="comments.xml">
+<link rel="alternate" type="application/rss+xml" title="Broken" href="/broken.xml">
+<link rel="stylesheet" href="/style.css">
+</head><body></body></html>`)
+		case "/posts.xml":
+			fmt.Fprintf(w, discoverFeed, "Blog posts")
+		case "/comments.xml":
+			fmt.Fprintf(w, discoverFeed, "Blog comments")
+		case "/broken.xml":
+			fmt.Fprint(w, "<html>not a feed</html>")
+		case "/plain":
+			fmt.Fprint(w, `<html><body>no links here</body></html>`)
+		case "/feed.xml":
+			fmt.Fprintf(w, discoverFeed, "Common path")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cfgPath := filepath.Join(t.TempDir(), "config.yml")
+	test.HandleError(t, os.WriteFile(cfgPath, []byte("feeds: []\n"), 0644))
+	cfg, err := config.New(cfgPath, "", []string{}, "test")
+	test.HandleError(t, err)
+	test.HandleError(t, cfg.Load())
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+
+	var offered []string
+	second := func(cs []rss.Candidate) (rss.Candidate, error) {
+		for _, c := range cs {
+			offered = append(offered, c.Title)
+		}
+		return cs[1], nil
+	}
+
+	test.HandleError(t, c.add(srv.URL+"/blog", "", nil, false, second))
+	test.Equal(t, "Blog posts,Blog comments", strings.Join(offered, ","), "expected only feeds that parse to be offered")
+
+	test.HandleError(t, c.add(srv.URL+"/plain", "", []string{"misc"}, false, second))
+	test.HandleError(t, c.add(srv.URL+"/posts.xml", "Mine", nil, false, second))
+
+	test.HandleError(t, c.add(srv.URL+"/nothing", "", nil, false, second))
+	test.HandleError(t, c.add(srv.URL+"/blog", "Unchecked", nil, true, second))
+
+	test.HandleError(t, cfg.Load())
+	test.Equal(t, 5, len(cfg.Feeds), "expected five feeds to be added")
+	test.Equal(t, srv.URL+"/comments.xml", cfg.Feeds[0].URL, "expected chosen feed")
+	test.Equal(t, "Blog comments", cfg.Feeds[0].Name, "expected name from the feed title")
+	test.Equal(t, srv.URL+"/feed.xml", cfg.Feeds[1].URL, "expected common path to be found")
+	test.Equal(t, "Common path", cfg.Feeds[1].Name, "expected name from the feed title")
+	test.Equal(t, "Mine", cfg.Feeds[2].Name, "expected given name to be kept")
+	test.Equal(t, srv.URL+"/nothing", cfg.Feeds[3].URL, "expected a failed check to add the url as given")
+	test.Equal(t, srv.URL+"/blog", cfg.Feeds[4].URL, "expected no-check to add the url as given")
+
+	chosen, err := chooseFeed(strings.NewReader("x\n9\n2\n"), &strings.Builder{}, []rss.Candidate{{URL: "a"}, {URL: "b"}})
+	test.HandleError(t, err)
+	test.Equal(t, "b", chosen.URL, "expected invalid answers to be asked again")
+}
@@ -0,0 +1,243 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/rss"
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+// ErrNoEnclosures is returned when there's nothing attached to an item to
+// play or download
+var ErrNoEnclosures = errors.New("item has no enclosures")
+
+func storeEnclosures(encs []rss.Enclosure) []store.Enclosure {
+	var ses []store.Enclosure
+	for _, e := range encs {
+		ses = append(ses, store.Enclosure{
+			URL:      e.URL,
+			Type:     e.Type,
+			Length:   e.Length,
+			Duration: e.Duration,
+		})
+	}
+	return ses
+}
+
+// playableEnclosure picks the enclosure the play key hands to a player,
+// preferring audio and video over anything else attached
+func playableEnclosure(encs []store.Enclosure) (store.Enclosure, bool) {
+	for _, e := range encs {
+		if strings.HasPrefix(e.Type, "audio/") || strings.HasPrefix(e.Type, "video/") {
+			return e, true
+		}
+	}
+
+	if len(encs) > 0 {
+		return encs[0], true
+	}
+
+	return store.Enclosure{}, false
+}
+
+// enclosuresMd lists the enclosures of an item for the article view
+func enclosuresMd(item store.Item) string {
+	var lines []string
+	for _, e := range item.Enclosures {
+		var details []string
+		if e.Type != "" {
+			details = append(details, e.Type)
+		}
+		if e.Length > 0 {
+			details = append(details, formatBytes(e.Length))
+		}
+		if e.Duration != "" {
+			details = append(details, e.Duration)
+		}
+
+		line := "- " + e.URL
+		if len(details) > 0 {
+			line += " (" + strings.Join(details, ", ") + ")"
+		}
+		lines = append(lines, line)
+	}
+
+	if item.Image != "" {
+		lines = append(lines, "- Image: "+item.Image)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Download saves the enclosures of an item to dir. Partial downloads are
+// kept with a .part suffix and resumed on the next run.
+func (c Commands) Download(ID int, dir string) error {
+	item, err := c.store.GetItemByID(ID)
+	if err != nil {
+		return fmt.Errorf("commands Download: %w", err)
+	}
+
+	if len(item.Enclosures) == 0 {
+		return fmt.Errorf("commands Download: %d: %w", ID, ErrNoEnclosures)
+	}
+
+	if dir == "" {
+		dir = "."
+	}
+
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("commands Download: %w", err)
+	}
+
+	// enclosures are often behind the same auth as the feed, which is sent
+	// when they're on the feed's host
+	feed := c.feedFor(item.FeedURL)
+
+	client, err := rss.NewClient(c.config.FeedHTTPOptions(feed))
+	if err != nil {
+		return fmt.Errorf("commands Download: %w", err)
+	}
+	// downloads can take far longer than fetching a feed
+	client.Timeout = 0
+
+	seen := map[string]int{}
+	for i, e := range item.Enclosures {
+		name := enclosureFilename(e.URL, fmt.Sprintf("nom-%d-%d", ID, i+1))
+		if n := seen[name]; n > 0 {
+			ext := filepath.Ext(name)
+			name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), n+1, ext)
+		}
+		seen[name]++
+
+		dest := filepath.Join(dir, name)
+		if _, err := os.Stat(dest); err == nil {
+			fmt.Printf("%s already downloaded\n", dest)
+			continue
+		}
+
+		n, err := c.downloadFile(client, c.config.LinkHTTPOptions(feed, e.URL), e.URL, dest)
+		if err != nil {
+			return fmt.Errorf("commands Download: %w", err)
+		}
+
+		fmt.Printf("saved %s (%s)\n", dest, formatBytes(n))
+	}
+
+	return nil
+}
+
+// downloadFile fetches url into dest via dest.part, resuming from the end of
+// an existing part file if the server supports range requests. It returns the
+// size of the finished file.
+func (c Commands) downloadFile(client *http.Client, opts *config.HTTPOptions, u string, dest string) (int64, error) {
+	part := dest + ".part"
+
+	var offset int64
+	if fi, err := os.Stat(part); err == nil {
+		offset = fi.Size()
+	}
+
+	req, err := rss.NewRequest(u, opts, c.config.Version)
+	if err != nil {
+		return 0, fmt.Errorf("downloadFile: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("downloadFile: %w", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the part file already holds everything
+		return offset, os.Rename(part, dest)
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		// no range support, start again
+		flags |= os.O_TRUNC
+		offset = 0
+	default:
+		return 0, fmt.Errorf("downloadFile: %s: %s", u, resp.Status)
+	}
+
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("downloadFile: %w", err)
+	}
+
+	n, err := io.Copy(f, resp.Body)
+	if err != nil {
+		f.Close()
+		return 0, fmt.Errorf("downloadFile: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return 0, fmt.Errorf("downloadFile: %w", err)
+	}
+
+	err = os.Rename(part, dest)
+	if err != nil {
+		return 0, fmt.Errorf("downloadFile: %w", err)
+	}
+
+	return offset + n, nil
+}
+
+// enclosureFilename takes the file name from the URL path, using fallback if
+// there isn't a usable one
+func enclosureFilename(u string, fallback string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fallback
+	}
+
+	name := path.Base(parsed.Path)
+	if name == "." || name == "/" || name == "" {
+		return fallback
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+
+	if strings.HasPrefix(name, ".") {
+		return fallback
+	}
+
+	return name
+}
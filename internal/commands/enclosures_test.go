@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+func TestDownload(t *testing.T) {
+	audio := bytes.Repeat([]byte("nom"), 1000)
+
+	var ranges []string
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/feed" {
+			fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>pod</title>
+<item><title>Episode 1</title><link>%[1]s/1</link><enclosure url="%[1]s/media/episode1.mp3" type="audio/mpeg" length="%[2]d"/></item>
+</channel></rss>`, srvURL, len(audio))
+			return
+		}
+
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "episode1.mp3", time.Time{}, bytes.NewReader(audio))
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(&config.Config{Feeds: []config.Feed{{URL: srv.URL + "/feed"}}}, s)
+
+	items, _, err := c.fetchAllFeeds()
+	test.HandleError(t, err)
+
+	it, err := s.GetItemByID(items[0].ID)
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(it.Enclosures), "expected enclosure to be stored")
+	test.Equal(t, int64(len(audio)), it.Enclosures[0].Length, "expected enclosure length")
+	test.Equal(t, true, strings.Contains(enclosuresMd(it), "audio/mpeg, 2.9 KB"), "expected enclosure details in article")
+
+	// an interrupted download is picked up where it stopped
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "episode1.mp3")
+	test.HandleError(t, os.WriteFile(dest+".part", audio[:1000], 0644))
+
+	test.HandleError(t, c.Download(it.ID, dir))
+
+	got, err := os.ReadFile(dest)
+	test.HandleError(t, err)
+	test.Equal(t, true, bytes.Equal(audio, got), "expected resumed download to match")
+	test.Equal(t, "bytes=1000-", strings.Join(ranges, ","), "expected download to resume with a range request")
+
+	_, err = os.Stat(dest + ".part")
+	test.Equal(t, true, os.IsNotExist(err), "expected part file to be renamed")
+
+	// finished downloads aren't fetched again
+	test.HandleError(t, c.Download(it.ID, dir))
+	test.Equal(t, 1, len(ranges), "expected no second request")
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+)
+
+const opmlVersion = "2.0"
+
+// tagSeparator splits a tag into nested category outlines, e.g. "tech/go"
+const tagSeparator = "/"
+
+func (o Outline) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "outline"}
+	start.Attr = []xml.Attr{{Name: xml.Name{Local: "text"}, Value: o.Text}}
+
+	if o.Title != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "title"}, Value: o.Title})
+	}
+	if o.Type != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "type"}, Value: string(o.Type)})
+	}
+	if o.XMLUrl != nil {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlUrl"}, Value: o.XMLUrl.String()})
+	}
+	if o.Category != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "category"}, Value: o.Category})
+	}
+
+	err := e.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+
+	for _, child := range o.Outlines {
+		err = e.Encode(child)
+		if err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// buildOPML turns feeds into an OPML document. A feed is listed once, under
+// its first tag or at the top level without tags, and all of its tags are kept
+// in the category attribute for importing.
+func buildOPML(feeds []config.Feed) (*OPML, error) {
+	opml := &OPML{
+		Version: opmlVersion,
+		Head: Head{
+			Title:       "nom subscriptions",
+			DateCreated: time.Now().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, feed := range feeds {
+		URL, err := url.Parse(feed.URL)
+		if err != nil {
+			return nil, fmt.Errorf("buildOPML: invalid URL for %s: %w", feed.URL, err)
+		}
+
+		// OPML requires text, title is left empty for an unnamed feed so it
+		// is imported without a name again
+		outline := Outline{Text: feed.Name, Title: feed.Name, Type: RssOutlineType, XMLUrl: URL}
+		if outline.Text == "" {
+			outline.Text = feed.URL
+		}
+
+		parent := &opml.Body.Outlines
+		if len(feed.Tags) > 0 {
+			categories := make([]string, len(feed.Tags))
+			for i, tag := range feed.Tags {
+				categories[i] = tagSeparator + tag
+			}
+			outline.Category = strings.Join(categories, ",")
+
+			for _, cat := range strings.Split(feed.Tags[0], tagSeparator) {
+				parent = &categoryOutline(parent, strings.TrimSpace(cat)).Outlines
+			}
+		}
+		*parent = append(*parent, outline)
+	}
+
+	return opml, nil
+}
+
+// categoryOutline returns the category outline named name in outlines,
+// adding it if it isn't there yet
+func categoryOutline(outlines *[]Outline, name string) *Outline {
+	for i, o := range *outlines {
+		if o.XMLUrl == nil && o.Text == name {
+			return &(*outlines)[i]
+		}
+	}
+
+	*outlines = append(*outlines, Outline{Text: name, Title: name})
+	return &(*outlines)[len(*outlines)-1]
+}
+
+func writeOPML(w io.Writer, opml *OPML) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(opml)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// ExportFeeds writes the configured feeds as OPML to output, or stdout when
+// output is empty. Feeds from backends are only included with withBackends.
+func (c Commands) ExportFeeds(output string, withBackends bool) error {
+	var feeds []config.Feed
+	for _, feed := range c.config.Feeds {
+		if feed.Backend != "" && !withBackends {
+			continue
+		}
+		feeds = append(feeds, feed)
+	}
+
+	opml, err := buildOPML(feeds)
+	if err != nil {
+		return fmt.Errorf("commands ExportFeeds: %w", err)
+	}
+
+	w := os.Stdout
+	if output != "" {
+		w, err = os.Create(output)
+		if err != nil {
+			return fmt.Errorf("commands ExportFeeds: %w", err)
+		}
+		defer w.Close()
+	}
+
+	err = writeOPML(w, opml)
+	if err != nil {
+		return fmt.Errorf("commands ExportFeeds: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+func TestExportOPML(t *testing.T) {
+	feeds := []config.Feed{
+		{URL: "http://example.com/untagged.xml", Name: "Untagged"},
+		{URL: "http://example.com/go.xml", Name: "Go", Tags: []string{"tech/go", "daily"}},
+		{URL: "http://example.com/tech.xml", Tags: []string{"tech"}},
+	}
+
+	opml, err := buildOPML(feeds)
+	test.HandleError(t, err)
+
+	var buf bytes.Buffer
+	err = writeOPML(&buf, opml)
+	test.HandleError(t, err)
+
+	result, err := parseOPML(buf.Bytes())
+	test.HandleError(t, err)
+
+	test.Equal(t, "2.0", result.Version, "incorrect opml version")
+	test.Equal(t, 2, len(result.Body.Outlines), "expected untagged feed and one category")
+
+	untagged := result.Body.Outlines[0]
+	test.Equal(t, "Untagged", untagged.Title, "wrong untagged feed")
+	test.Equal(t, RssOutlineType, untagged.Type, "wrong outline type")
+	test.Equal(t, "http://example.com/untagged.xml", untagged.XMLUrl.String(), "wrong untagged url")
+
+	tech := result.Body.Outlines[1]
+	test.Equal(t, "tech", tech.Text, "wrong category")
+	test.Equal(t, 2, len(tech.Outlines), "expected nested category and feed in tech")
+	test.Equal(t, "go", tech.Outlines[0].Text, "wrong nested category")
+	test.Equal(t, "http://example.com/go.xml", tech.Outlines[0].Outlines[0].XMLUrl.String(), "wrong nested feed")
+	test.Equal(t, "http://example.com/tech.xml", tech.Outlines[1].Text, "expected url as text for unnamed feed")
+	test.Equal(t, "", tech.Outlines[1].Title, "expected no title for unnamed feed")
+
+	// a feed is only listed under its first tag, the rest survive an import
+	goFeed := tech.Outlines[0].Outlines[0]
+	test.Equal(t, "/tech/go,/daily", goFeed.Category, "wrong categories")
+	tags := goFeed.Tags()
+	test.Equal(t, 2, len(tags), "expected both tags back")
+	test.Equal(t, "tech/go", tags[0], "wrong first tag")
+	test.Equal(t, "daily", tags[1], "wrong second tag")
+}
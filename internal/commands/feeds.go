@@ -2,10 +2,13 @@ package commands
 
 import (
 	"fmt"
-	"sync"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/guyfedwards/nom/v2/internal/config"
-	"github.com/guyfedwards/nom/v2/internal/rss"
 	"github.com/guyfedwards/nom/v2/internal/store"
 )
 
@@ -57,6 +60,9 @@ func (c Commands) GetAllFeeds() ([]store.Item, error) {
 		return []store.Item{}, fmt.Errorf("commands.go: GetAllFeeds %w", err)
 	}
 
+	c.addFeedInfo(is)
+	is = collapseDuplicates(withoutHidden(is))
+
 	if c.config.ShowFavourites {
 		is = onlyFavourites(is)
 	} else if c.config.ShowRead {
@@ -65,7 +71,46 @@ func (c Commands) GetAllFeeds() ([]store.Item, error) {
 		is = defaultView(is)
 	}
 
-	// add FeedName from config for custom names
+	return is, nil
+}
+
+// collapseDuplicates keeps the first of an item and its copies from other
+// feeds, with Sources naming every feed it came from
+func collapseDuplicates(items []store.Item) []store.Item {
+	first := map[int]int{}
+
+	var is []store.Item
+	for _, v := range items {
+		group := v.ID
+		if v.DuplicateOf != 0 {
+			group = v.DuplicateOf
+		}
+
+		source := v.FeedName
+		if source == "" {
+			source = v.FeedURL
+		}
+
+		i, ok := first[group]
+		if !ok {
+			first[group] = len(is)
+			v.Sources = []string{source}
+			is = append(is, v)
+			continue
+		}
+
+		if !slices.Contains(is[i].Sources, source) {
+			is[i].Sources = append(is[i].Sources, source)
+		}
+		// keep the item favourited if any copy is
+		is[i].Favourite = is[i].Favourite || v.Favourite
+	}
+
+	return is
+}
+
+// addFeedInfo adds FeedName and Tags from config for custom names
+func (c Commands) addFeedInfo(is []store.Item) {
 	for i := 0; i < len(is); i++ {
 		for _, f := range c.config.Feeds {
 			if f.URL == is[i].FeedURL {
@@ -74,8 +119,17 @@ func (c Commands) GetAllFeeds() ([]store.Item, error) {
 			}
 		}
 	}
+}
 
-	return is, nil
+// withoutHidden drops items muted by a rule
+func withoutHidden(items []store.Item) (is []store.Item) {
+	for _, v := range items {
+		if !v.Hidden {
+			is = append(is, v)
+		}
+	}
+
+	return is
 }
 
 func onlyFavourites(items []store.Item) (is []store.Item) {
@@ -103,14 +157,87 @@ func defaultView(items []store.Item) (is []store.Item) {
 	return is
 }
 
-func fetchFeed(ch chan FetchResultError, wg *sync.WaitGroup, feed config.Feed, httpOpts *config.HTTPOptions, version string) {
-	defer wg.Done()
+// ListFeeds prints each feed with its item counts and last fetch status
+func (c Commands) ListFeeds() error {
+	stats, err := c.store.GetFeedStats()
+	if err != nil {
+		return fmt.Errorf("commands ListFeeds: %w", err)
+	}
+
+	metas, err := c.store.GetAllFeedMeta()
+	if err != nil {
+		return fmt.Errorf("commands ListFeeds: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tURL\tTAGS\tITEMS\tUNREAD\tLAST FETCH\tNEXT FETCH\tSTATUS")
+
+	for _, f := range c.config.GetFeeds() {
+		st := stats[f.URL]
+		meta := metas[f.URL]
+
+		lastFetch := "never"
+		if !meta.LastFetchedAt.IsZero() {
+			lastFetch = meta.LastFetchedAt.Local().Format(time.DateTime)
+		}
+
+		nextFetch := "-"
+		if !meta.NextFetchAt.IsZero() {
+			nextFetch = meta.NextFetchAt.Local().Format(time.DateTime)
+		}
+
+		status := "ok"
+		if meta.LastError != "" {
+			status = "error: " + meta.LastError
+		} else if meta.LastFetchedAt.IsZero() {
+			status = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\t%s\t%s\n", f.Name, f.URL, strings.Join(f.Tags, ","), st.Items, st.Unread, lastFetch, nextFetch, status)
+	}
+
+	return w.Flush()
+}
+
+// RemoveFeed removes a feed from the config and deletes its stored items,
+// apart from favourites
+func (c Commands) RemoveFeed(ref string) error {
+	feed, err := c.config.RemoveFeed(ref)
+	if err != nil {
+		return fmt.Errorf("commands RemoveFeed: %w", err)
+	}
 
-	r, err := rss.Fetch(feed, httpOpts, version)
+	err = c.store.DeleteByFeedURL(feed.URL, false)
 	if err != nil {
-		ch <- FetchResultError{res: rss.RSS{}, err: err, url: feed.URL}
-		return
+		return fmt.Errorf("commands RemoveFeed: %w", err)
 	}
 
-	ch <- FetchResultError{res: r, err: nil, url: feed.URL}
+	return nil
+}
+
+func (c Commands) RenameFeed(ref string, name string) error {
+	err := c.config.RenameFeed(ref, name)
+	if err != nil {
+		return fmt.Errorf("commands RenameFeed: %w", err)
+	}
+
+	return nil
+}
+
+func (c Commands) TagFeed(ref string, tags []string) error {
+	err := c.config.TagFeed(ref, tags)
+	if err != nil {
+		return fmt.Errorf("commands TagFeed: %w", err)
+	}
+
+	return nil
+}
+
+func (c Commands) UntagFeed(ref string, tags []string) error {
+	err := c.config.UntagFeed(ref, tags)
+	if err != nil {
+		return fmt.Errorf("commands UntagFeed: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/rss"
+)
+
+type fetchJob struct {
+	feed  config.Feed
+	cache rss.CacheHeaders
+}
+
+// fetcher fetches feeds with a bounded number of workers, a limit per host,
+// and retries with backoff for errors that are likely to pass
+type fetcher struct {
+	workers    int
+	perHost    int
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	httpOpts *config.HTTPOptions
+	version  string
+
+	// fetch and sleep are swapped out in tests
+	fetch func(f config.Feed, httpOpts *config.HTTPOptions, version string, cache rss.CacheHeaders) (rss.RSS, error)
+	sleep func(time.Duration)
+
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
+}
+
+func (c Commands) newFetcher() *fetcher {
+	backoff, maxBackoff := c.config.Fetch.BackoffDurations()
+
+	return &fetcher{
+		workers:    c.config.Fetch.WorkerCount(),
+		perHost:    c.config.Fetch.PerHostLimit(),
+		retries:    c.config.Fetch.RetryCount(),
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+		httpOpts:   c.config.HTTPOptions,
+		version:    c.config.Version,
+		fetch:      rss.Fetch,
+		sleep:      time.Sleep,
+		hosts:      map[string]chan struct{}{},
+	}
+}
+
+// run fetches the feeds of jobs, sending a result for each on the returned
+// channel, which is closed once all are done
+func (f *fetcher) run(jobs []fetchJob) <-chan FetchResultError {
+	queue := make(chan fetchJob)
+	results := make(chan FetchResultError)
+
+	var wg sync.WaitGroup
+	for range min(f.workers, len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				results <- f.fetchWithRetry(j)
+			}
+		}()
+	}
+
+	go func() {
+		for _, j := range interleaveByHost(jobs) {
+			queue <- j
+		}
+		close(queue)
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+func (f *fetcher) fetchWithRetry(j fetchJob) FetchResultError {
+	start := time.Now()
+	host := feedHost(j.feed.URL)
+
+	var (
+		r   rss.RSS
+		err error
+	)
+	for attempt := 0; ; attempt++ {
+		release := f.acquire(host)
+		r, err = f.fetch(j.feed, f.httpOpts, f.version, j.cache)
+		release()
+
+		if err == nil || attempt >= f.retries || !isTransient(r, err) {
+			break
+		}
+
+		wait := f.backoffFor(attempt)
+		if r.RetryAfter > 0 {
+			// asked to wait longer than we're willing to, so report it
+			if r.RetryAfter > f.maxBackoff {
+				break
+			}
+			wait = r.RetryAfter
+		}
+
+		f.sleep(wait)
+	}
+
+	return FetchResultError{res: r, err: err, url: j.feed.URL, fetchedAt: start, duration: time.Since(start)}
+}
+
+// backoffFor is the wait before retrying after attempt, doubling from
+// backoff up to maxBackoff
+func (f *fetcher) backoffFor(attempt int) time.Duration {
+	wait := f.backoff
+	for range attempt {
+		if wait >= f.maxBackoff {
+			break
+		}
+		wait *= 2
+	}
+	return min(wait, f.maxBackoff)
+}
+
+// acquire waits for a free slot for host, returning the func to release it.
+// Feeds without a host, e.g. local files, aren't limited.
+func (f *fetcher) acquire(host string) func() {
+	if host == "" {
+		return func() {}
+	}
+
+	f.mu.Lock()
+	sem, ok := f.hosts[host]
+	if !ok {
+		sem = make(chan struct{}, f.perHost)
+		f.hosts[host] = sem
+	}
+	f.mu.Unlock()
+
+	sem <- struct{}{}
+	return func() { <-sem }
+}
+
+// isTransient reports whether a failed fetch is worth retrying
+func isTransient(r rss.RSS, err error) bool {
+	if errors.Is(err, rss.ErrNotModified) {
+		return false
+	}
+
+	if r.StatusCode == 429 || r.StatusCode >= 500 {
+		return true
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func feedHost(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	return parsed.Host
+}
+
+// interleaveByHost orders jobs round robin across hosts, so that workers
+// aren't all left waiting on one host's limit while other hosts are idle
+func interleaveByHost(jobs []fetchJob) []fetchJob {
+	var hosts []string
+	byHost := map[string][]fetchJob{}
+	for _, j := range jobs {
+		h := feedHost(j.feed.URL)
+		if _, ok := byHost[h]; !ok {
+			hosts = append(hosts, h)
+		}
+		byHost[h] = append(byHost[h], j)
+	}
+
+	ordered := make([]fetchJob, 0, len(jobs))
+	for len(ordered) < len(jobs) {
+		for _, h := range hosts {
+			if len(byHost[h]) > 0 {
+				ordered = append(ordered, byHost[h][0])
+				byHost[h] = byHost[h][1:]
+			}
+		}
+	}
+
+	return ordered
+}
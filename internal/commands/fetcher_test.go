@@ -0,0 +1,143 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/rss"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+func TestFetcherRetries(t *testing.T) {
+	var attempts sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := attempts.LoadOrStore(r.URL.Path, new(atomic.Int32))
+		count := n.(*atomic.Int32).Add(1)
+
+		switch r.URL.Path {
+		case "/flaky":
+			if count < 3 {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/down":
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		case "/gone":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "/slow-down":
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>ok</title></channel></rss>`)
+	}))
+	defer srv.Close()
+
+	c := New(&config.Config{Fetch: &config.FetchOptions{Retries: 3, Backoff: "1s", MaxBackoff: "10s"}}, nil)
+	f := c.newFetcher()
+
+	var mu sync.Mutex
+	var waits []time.Duration
+	f.sleep = func(d time.Duration) {
+		mu.Lock()
+		waits = append(waits, d)
+		mu.Unlock()
+	}
+
+	var jobs []fetchJob
+	for _, p := range []string{"/flaky", "/down", "/gone", "/slow-down"} {
+		jobs = append(jobs, fetchJob{feed: config.Feed{URL: srv.URL + p}})
+	}
+
+	results := map[string]FetchResultError{}
+	for r := range f.run(jobs) {
+		results[r.url[len(srv.URL):]] = r
+	}
+
+	count := func(p string) int32 {
+		n, _ := attempts.Load(p)
+		return n.(*atomic.Int32).Load()
+	}
+
+	test.HandleError(t, results["/flaky"].err)
+	test.Equal(t, int32(3), count("/flaky"), "expected flaky feed to succeed on the third attempt")
+	test.Equal(t, int32(4), count("/down"), "expected 5xx to be retried until retries run out")
+	test.Equal(t, true, results["/down"].err != nil, "expected failure to be reported")
+	test.Equal(t, int32(1), count("/gone"), "expected 404 not to be retried")
+	test.Equal(t, int32(1), count("/slow-down"), "expected a Retry-After beyond the max backoff not to be waited for")
+	test.Equal(t, 429, results["/slow-down"].res.StatusCode, "expected status to be kept")
+
+	var flakyWaits, downWaits []time.Duration
+	for _, w := range waits {
+		if w == 7*time.Second {
+			flakyWaits = append(flakyWaits, w)
+		} else {
+			downWaits = append(downWaits, w)
+		}
+	}
+	test.Equal(t, 2, len(flakyWaits), "expected Retry-After to be respected")
+	test.Equal(t, fmt.Sprint([]time.Duration{time.Second, 2 * time.Second, 4 * time.Second}), fmt.Sprint(downWaits), "expected exponential backoff")
+}
+
+func TestFetcherLimits(t *testing.T) {
+	var mu sync.Mutex
+	inFlight := map[string]int{}
+	maxHost := map[string]int{}
+	total, maxTotal := 0, 0
+
+	c := New(&config.Config{Fetch: &config.FetchOptions{Workers: 4, PerHost: 2}}, nil)
+	f := c.newFetcher()
+	f.fetch = func(feed config.Feed, _ *config.HTTPOptions, _ string, _ rss.CacheHeaders) (rss.RSS, error) {
+		host := feedHost(feed.URL)
+
+		mu.Lock()
+		inFlight[host]++
+		total++
+		maxHost[host] = max(maxHost[host], inFlight[host])
+		maxTotal = max(maxTotal, total)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		inFlight[host]--
+		total--
+		mu.Unlock()
+		return rss.RSS{}, nil
+	}
+
+	var jobs []fetchJob
+	for i := range 30 {
+		host := []string{"a.example", "b.example", "c.example"}[i%3]
+		jobs = append(jobs, fetchJob{feed: config.Feed{URL: fmt.Sprintf("https://%s/%d", host, i)}})
+	}
+
+	n := 0
+	for range f.run(jobs) {
+		n++
+	}
+
+	test.Equal(t, 30, n, "expected a result per feed")
+	test.Equal(t, true, maxTotal <= 4, "expected at most 4 fetches at once")
+	for h, m := range maxHost {
+		test.Equal(t, true, m <= 2, "expected at most 2 fetches at once to "+h)
+	}
+}
+
+func TestFetcherBackoff(t *testing.T) {
+	f := &fetcher{backoff: time.Second, maxBackoff: time.Minute}
+
+	test.Equal(t, time.Second, f.backoffFor(0), "wrong first backoff")
+	test.Equal(t, 8*time.Second, f.backoffFor(3), "expected backoff to double")
+	// a shift this far would overflow to a negative duration
+	test.Equal(t, time.Minute, f.backoffFor(64), "expected backoff to stop at the max")
+}
@@ -0,0 +1,396 @@
+package commands
+
+import (
+	"cmp"
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"hash/crc32"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+const (
+	feverAPIVersion = 3
+	// feverPageSize is the number of items returned per items request
+	feverPageSize = 50
+)
+
+type feverGroup struct {
+	ID    uint32 `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeedsGroup struct {
+	GroupID uint32 `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feverFeed struct {
+	ID                uint32 `json:"id"`
+	FaviconID         int    `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type feverItem struct {
+	ID            int    `json:"id"`
+	FeedID        uint32 `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// feverID gives feeds and groups, which nom identifies by url and tag name,
+// the stable integer ids Fever expects
+func feverID(s string) uint32 {
+	return crc32.ChecksumIEEE([]byte(s))
+}
+
+func feverBool(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func joinIDs[T int | uint32](ids []T) string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = strconv.FormatInt(int64(id), 10)
+	}
+	return strings.Join(strs, ",")
+}
+
+// feverAPIKey is what clients send to authenticate, md5 of "username:password"
+func feverAPIKey(fc *config.FeverConfig) string {
+	sum := md5.Sum([]byte(fc.Username + ":" + fc.Password))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c Commands) feverHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := r.ParseForm()
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		res := map[string]any{"api_version": feverAPIVersion, "auth": 0}
+
+		want := feverAPIKey(c.config.Fever)
+		got := strings.ToLower(r.Form.Get("api_key"))
+		if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			writeAPIJSON(w, http.StatusOK, res)
+			return
+		}
+		res["auth"] = 1
+
+		err = c.feverRespond(r, res)
+		if err != nil {
+			log.Printf("[fever.go] feverHandler: %v", err)
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		writeAPIJSON(w, http.StatusOK, res)
+	})
+}
+
+// feverRespond fills res for each of the requests in r, as clients may ask
+// for several at once
+func (c Commands) feverRespond(r *http.Request, res map[string]any) error {
+	q := r.Form
+
+	// marks come first so the rest of the response reflects them
+	if q.Has("mark") {
+		err := c.feverMark(q.Get("mark"), q.Get("as"), q.Get("id"), q.Get("before"))
+		if err != nil {
+			return err
+		}
+	}
+
+	metas, err := c.store.GetAllFeedMeta()
+	if err != nil {
+		return err
+	}
+
+	var lastRefreshed time.Time
+	for _, m := range metas {
+		if m.LastFetchedAt.After(lastRefreshed) {
+			lastRefreshed = m.LastFetchedAt
+		}
+	}
+	res["last_refreshed_on_time"] = unixOrZero(lastRefreshed)
+
+	feeds := c.config.GetFeeds()
+
+	if q.Has("groups") {
+		res["groups"] = feverGroups(feeds)
+		res["feeds_groups"] = feverFeedsGroups(feeds)
+	}
+
+	if q.Has("feeds") {
+		ff := []feverFeed{}
+		for _, f := range feeds {
+			title := f.Name
+			if title == "" {
+				title = f.URL
+			}
+
+			ff = append(ff, feverFeed{
+				ID:                feverID(f.URL),
+				Title:             title,
+				URL:               f.URL,
+				SiteURL:           f.URL,
+				LastUpdatedOnTime: unixOrZero(metas[f.URL].LastFetchedAt),
+			})
+		}
+		res["feeds"] = ff
+		res["feeds_groups"] = feverFeedsGroups(feeds)
+	}
+
+	if q.Has("favicons") {
+		res["favicons"] = []any{}
+	}
+
+	if q.Has("links") {
+		res["links"] = []any{}
+	}
+
+	if !q.Has("items") && !q.Has("unread_item_ids") && !q.Has("saved_item_ids") {
+		return nil
+	}
+
+	its, err := c.store.GetAllItems("asc")
+	if err != nil {
+		return err
+	}
+	its = withoutHidden(its)
+
+	if q.Has("items") {
+		res["items"] = feverItems(its, q.Get("since_id"), q.Get("max_id"), q.Get("with_ids"))
+		res["total_items"] = len(its)
+	}
+
+	if q.Has("unread_item_ids") {
+		var ids []int
+		for _, it := range its {
+			if !it.Read() {
+				ids = append(ids, it.ID)
+			}
+		}
+		res["unread_item_ids"] = joinIDs(ids)
+	}
+
+	if q.Has("saved_item_ids") {
+		var ids []int
+		for _, it := range its {
+			if it.Favourite {
+				ids = append(ids, it.ID)
+			}
+		}
+		res["saved_item_ids"] = joinIDs(ids)
+	}
+
+	return nil
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// feverGroups are the feed tags, in the order they first appear
+func feverGroups(feeds []config.Feed) []feverGroup {
+	groups := []feverGroup{}
+	seen := map[string]bool{}
+
+	for _, f := range feeds {
+		for _, tag := range f.Tags {
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			groups = append(groups, feverGroup{ID: feverID(tag), Title: tag})
+		}
+	}
+
+	return groups
+}
+
+func feverFeedsGroups(feeds []config.Feed) []feverFeedsGroup {
+	var order []string
+	members := map[string][]uint32{}
+
+	for _, f := range feeds {
+		for _, tag := range f.Tags {
+			if _, ok := members[tag]; !ok {
+				order = append(order, tag)
+			}
+			members[tag] = append(members[tag], feverID(f.URL))
+		}
+	}
+
+	fgs := []feverFeedsGroup{}
+	for _, tag := range order {
+		fgs = append(fgs, feverFeedsGroup{GroupID: feverID(tag), FeedIDs: joinIDs(members[tag])})
+	}
+
+	return fgs
+}
+
+// feverItems pages through its by id, as clients page with since_id and
+// max_id. since_id returns the items after it, max_id the items before it
+// newest first, and with_ids the listed items.
+func feverItems(its []store.Item, sinceID string, maxID string, withIDs string) []feverItem {
+	// the store orders by publish date, which isn't the order items get ids
+	its = slices.Clone(its)
+	slices.SortFunc(its, func(a, b store.Item) int { return cmp.Compare(a.ID, b.ID) })
+
+	var page []store.Item
+
+	switch {
+	case withIDs != "":
+		wanted := map[int]bool{}
+		for _, s := range strings.Split(withIDs, ",") {
+			if id, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+				wanted[id] = true
+			}
+		}
+		for _, it := range its {
+			if wanted[it.ID] && len(page) < feverPageSize {
+				page = append(page, it)
+			}
+		}
+
+	case maxID != "":
+		max, _ := strconv.Atoi(maxID)
+		for _, it := range slices.Backward(its) {
+			if (max <= 0 || it.ID < max) && len(page) < feverPageSize {
+				page = append(page, it)
+			}
+		}
+
+	default:
+		since, _ := strconv.Atoi(sinceID)
+		for _, it := range its {
+			if it.ID > since && len(page) < feverPageSize {
+				page = append(page, it)
+			}
+		}
+	}
+
+	items := []feverItem{}
+	for _, it := range page {
+		created := it.PublishedAt
+		if created.IsZero() {
+			created = it.CreatedAt
+		}
+
+		items = append(items, feverItem{
+			ID:            it.ID,
+			FeedID:        feverID(it.FeedURL),
+			Title:         it.Title,
+			Author:        it.Author,
+			HTML:          it.Content,
+			URL:           it.Link,
+			IsSaved:       feverBool(it.Favourite),
+			IsRead:        feverBool(it.Read()),
+			CreatedOnTime: unixOrZero(created),
+		})
+	}
+
+	return items
+}
+
+// feverMark applies a mark request. Items can be marked read, unread, saved
+// or unsaved. Feeds and groups can only be marked read, up to before, and
+// group 0 is every feed.
+func (c Commands) feverMark(mark string, as string, id string, before string) error {
+	if mark == "item" {
+		itemID, err := strconv.Atoi(id)
+		if err != nil {
+			return nil
+		}
+
+		it, err := c.store.GetItemByID(itemID)
+		if err != nil {
+			return nil
+		}
+
+		switch {
+		case as == "read", as == "unread":
+			return c.store.SetRead(it.ID, as == "read")
+		case as == "saved" && !it.Favourite, as == "unsaved" && it.Favourite:
+			return c.store.ToggleFavourite(it.ID)
+		}
+
+		return nil
+	}
+
+	if as != "read" || (mark != "feed" && mark != "group") {
+		return nil
+	}
+
+	markID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil
+	}
+
+	var cutoff time.Time
+	if b, err := strconv.ParseInt(before, 10, 64); err == nil && b > 0 {
+		cutoff = time.Unix(b, 0)
+	}
+
+	feedURLs := map[string]bool{}
+	for _, f := range c.config.GetFeeds() {
+		switch {
+		case mark == "feed" && feverID(f.URL) == uint32(markID),
+			mark == "group" && markID == 0,
+			mark == "group" && slices.ContainsFunc(f.Tags, func(t string) bool { return feverID(t) == uint32(markID) }):
+			feedURLs[f.URL] = true
+		}
+	}
+
+	its, err := c.store.GetAllItems("asc")
+	if err != nil {
+		return err
+	}
+
+	for _, it := range its {
+		if it.Read() || !feedURLs[it.FeedURL] {
+			continue
+		}
+
+		// before is when the client last fetched, leave anything newer
+		if !cutoff.IsZero() && it.CreatedAt.After(cutoff) {
+			continue
+		}
+
+		// an earlier copy may have read this one already, so set rather than
+		// toggle
+		err := c.store.SetRead(it.ID, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+type feverResponse struct {
+	APIVersion    int               `json:"api_version"`
+	Auth          int               `json:"auth"`
+	Groups        []feverGroup      `json:"groups"`
+	FeedsGroups   []feverFeedsGroup `json:"feeds_groups"`
+	Feeds         []feverFeed       `json:"feeds"`
+	Items         []feverItem       `json:"items"`
+	TotalItems    int               `json:"total_items"`
+	UnreadItemIDs string            `json:"unread_item_ids"`
+	SavedItemIDs  string            `json:"saved_item_ids"`
+}
+
+func feverRequest(t *testing.T, srv *httptest.Server, query string, form url.Values) feverResponse {
+	t.Helper()
+
+	resp, body := apiRequest(t, http.MethodPost, srv.URL+"/fever/?api&"+query, form.Encode(), "")
+	test.Equal(t, http.StatusOK, resp.StatusCode, "wrong status")
+
+	var fr feverResponse
+	test.HandleError(t, json.Unmarshal(body, &fr))
+
+	return fr
+}
+
+func TestFeverAPI(t *testing.T) {
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	for i, feed := range []string{"https://a.com/feed", "https://a.com/feed", "https://b.com/feed"} {
+		it := store.Item{FeedURL: feed, Link: fmt.Sprintf("%s/%d", feed, i), Title: fmt.Sprintf("item %d", i)}
+		_, err := s.UpsertItem(&it)
+		test.HandleError(t, err)
+	}
+
+	fc := &config.FeverConfig{Username: "me", Password: "secret"}
+	cfg := &config.Config{
+		Ordering: "asc",
+		Fever:    fc,
+		Feeds: []config.Feed{
+			{URL: "https://a.com/feed", Name: "A", Tags: []string{"news"}},
+			{URL: "https://b.com/feed", Tags: []string{"news", "tech"}},
+		},
+	}
+
+	// requests with a body need the form content type, which apiRequest
+	// doesn't set, so post through a wrapper that adds it
+	h := New(cfg, s).serverHandler(ServeOptions{Token: "unused", Fever: true})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	fr := feverRequest(t, srv, "", url.Values{"api_key": {"wrong"}})
+	test.Equal(t, 3, fr.APIVersion, "wrong api version")
+	test.Equal(t, 0, fr.Auth, "expected bad key to be rejected")
+
+	key := url.Values{"api_key": {strings.ToUpper(feverAPIKey(fc))}}
+
+	fr = feverRequest(t, srv, "groups&feeds", key)
+	test.Equal(t, 1, fr.Auth, "expected key to be accepted")
+	test.Equal(t, 2, len(fr.Groups), "expected a group per tag")
+	test.Equal(t, "news", fr.Groups[0].Title, "wrong group title")
+	test.Equal(t, 2, len(fr.Feeds), "expected every feed")
+	test.Equal(t, "A", fr.Feeds[0].Title, "expected feed name as title")
+	test.Equal(t, fmt.Sprintf("%d,%d", fr.Feeds[0].ID, fr.Feeds[1].ID), fr.FeedsGroups[0].FeedIDs, "wrong feeds in news group")
+
+	fr = feverRequest(t, srv, "items&since_id=1", key)
+	test.Equal(t, 2, len(fr.Items), "expected items after since_id")
+	test.Equal(t, 3, fr.TotalItems, "wrong total")
+	test.Equal(t, 0, len(fr.Feeds), "expected only items")
+
+	fr = feverRequest(t, srv, "items&max_id=3", key)
+	test.Equal(t, 2, fr.Items[0].ID, "expected newest first below max_id")
+
+	fr = feverRequest(t, srv, "items&with_ids=1,3", key)
+	test.Equal(t, 2, len(fr.Items), "expected listed items")
+
+	mark := url.Values{"api_key": key["api_key"], "mark": {"item"}, "as": {"saved"}, "id": {"2"}}
+	fr = feverRequest(t, srv, "saved_item_ids", mark)
+	test.Equal(t, "2", fr.SavedItemIDs, "expected item to be saved")
+
+	mark = url.Values{"api_key": key["api_key"], "mark": {"feed"}, "as": {"read"}, "id": {fmt.Sprint(feverID("https://a.com/feed"))}}
+	fr = feverRequest(t, srv, "unread_item_ids", mark)
+	test.Equal(t, "3", fr.UnreadItemIDs, "expected feed to be marked read")
+
+	mark = url.Values{"api_key": key["api_key"], "mark": {"item"}, "as": {"unread"}, "id": {"1"}}
+	fr = feverRequest(t, srv, "unread_item_ids", mark)
+	test.Equal(t, "1,3", fr.UnreadItemIDs, "expected item to be unread")
+
+	mark = url.Values{"api_key": key["api_key"], "mark": {"group"}, "as": {"read"}, "id": {"0"}}
+	fr = feverRequest(t, srv, "unread_item_ids", mark)
+	test.Equal(t, "", fr.UnreadItemIDs, "expected everything to be read")
+}
+
+func TestFeverPaging(t *testing.T) {
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	// later items were published earlier, so the store's order isn't by id
+	now := time.Now()
+	for i := range feverPageSize + 5 {
+		it := store.Item{
+			FeedURL:     "https://a.com/feed",
+			Link:        fmt.Sprintf("https://a.com/%d", i),
+			Title:       fmt.Sprintf("Item %d", i),
+			PublishedAt: now.Add(-time.Duration(i) * time.Hour),
+		}
+		_, err := s.UpsertItem(&it)
+		test.HandleError(t, err)
+	}
+
+	its, err := s.GetAllItems("asc")
+	test.HandleError(t, err)
+
+	seen := map[int]bool{}
+	since := "0"
+	for {
+		page := feverItems(its, since, "", "")
+		if len(page) == 0 {
+			break
+		}
+		for _, it := range page {
+			test.Equal(t, false, seen[it.ID], fmt.Sprintf("item %d repeated", it.ID))
+			seen[it.ID] = true
+		}
+		since = strconv.Itoa(page[len(page)-1].ID)
+	}
+	test.Equal(t, len(its), len(seen), "expected paging by since_id to reach every item")
+
+	first := slices.MinFunc(its, func(a, b store.Item) int { return a.ID - b.ID }).ID
+	page := feverItems(its, "", strconv.Itoa(first+3), "")
+	test.Equal(t, 3, len(page), "expected the items before max_id")
+	test.Equal(t, first+2, page[0].ID, "expected max_id pages newest first")
+}
@@ -1,9 +1,13 @@
 package commands
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
 )
 
 var testItems = []string{
@@ -297,3 +301,95 @@ func TestFilter_DefaultIncludeFeedName(t *testing.T) {
 		}
 	})
 }
+
+func TestFilter_BodySearch(t *testing.T) {
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	var targets []string
+	for _, it := range []store.Item{
+		{FeedURL: "a", Link: "a/1", Title: "Weekly notes", Content: "<p>a post about sqlite internals</p>"},
+		{FeedURL: "a", Link: "a/2", Title: "More notes", Content: "<p>nothing to see</p>"},
+		{FeedURL: "b", Link: "b/1", Title: "Release notes", Content: "<p>sqlite was upgraded</p>"},
+	} {
+		_, err = s.UpsertItem(&it)
+		test.HandleError(t, err)
+		targets = append(targets, TUIItem{ID: it.ID, Title: it.Title}.FilterValue())
+	}
+
+	testCases := []struct {
+		name          string
+		searchTerm    string
+		expectedCount int
+	}{
+		{name: "body only", searchTerm: "body:sqlite", expectedCount: 2},
+		{name: "body and title", searchTerm: "body:sqlite Release", expectedCount: 1},
+		{name: "multiple bodies", searchTerm: "body:sqlite body:internals", expectedCount: 1},
+		{name: "quoted phrase", searchTerm: `body:"was upgraded"`, expectedCount: 1},
+		{name: "no matches", searchTerm: "body:postgres", expectedCount: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filterer := NewFilterer(tc.searchTerm, config.Config{})
+			filterer.Store = s
+			matches := filterer.Filter(targets)
+
+			test.Equal(t, tc.expectedCount, len(matches), "wrong number of matches")
+		})
+	}
+}
+
+func TestFilter_Query(t *testing.T) {
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	now := time.Now()
+	var targets []string
+	for _, it := range []store.Item{
+		{FeedURL: "hn", FeedName: "hacker news", Tags: []string{"security"}, Link: "hn/1", Title: "New TLS attack", Author: "Alice", PublishedAt: now.Add(-48 * time.Hour)},
+		{FeedURL: "lwn", FeedName: "lwn", Tags: []string{"security", "linux"}, Link: "lwn/1", Title: "Kernel hardening", Author: "Bob", PublishedAt: now.Add(-72 * time.Hour)},
+		{FeedURL: "lwn", FeedName: "lwn", Tags: []string{"security", "linux"}, Link: "lwn/2", Title: "Old CVE roundup", Author: "Bob", PublishedAt: now.Add(-30 * 24 * time.Hour)},
+		{FeedURL: "blog", FeedName: "ai blog", Tags: []string{"ai/llm"}, Link: "blog/1", Title: "Attention again", Author: "Carol", PublishedAt: time.Date(2026, 1, 2, 12, 0, 0, 0, time.Local)},
+	} {
+		_, err = s.UpsertItem(&it)
+		test.HandleError(t, err)
+		targets = append(targets, ItemToTUIItem(it).FilterValue())
+	}
+	test.HandleError(t, s.ToggleRead(2))
+	test.HandleError(t, s.ToggleFavourite(4))
+
+	testCases := []struct {
+		name       string
+		searchTerm string
+		expected   []int
+	}{
+		{name: "tag and not feed", searchTerm: "tag:security -feed:hacker age:<7d", expected: []int{1}},
+		{name: "or", searchTerm: "feed:hacker OR feed:blog", expected: []int{0, 3}},
+		{name: "not keyword", searchTerm: "tag:security NOT Kernel", expected: []int{0, 2}},
+		{name: "parentheses", searchTerm: "(author:alice OR author:carol) -is:fav", expected: []int{0}},
+		{name: "negated group", searchTerm: "-(tag:linux OR tag:ai)", expected: []int{0}},
+		{name: "parent tag", searchTerm: "tag:ai", expected: []int{3}},
+		{name: "is read", searchTerm: "is:read", expected: []int{1}},
+		{name: "is unread and fav", searchTerm: "is:unread is:fav", expected: []int{3}},
+		{name: "after and before", searchTerm: "after:2026-01-01 before:2026-01-03", expected: []int{3}},
+		{name: "older than", searchTerm: "age:>7d", expected: []int{2, 3}},
+		{name: "incomplete terms are ignored", searchTerm: `tag:linux age:< feed:"lw`, expected: []int{1, 2}},
+		{name: "apostrophes in words", searchTerm: "don't", expected: nil},
+		{name: "stray parentheses", searchTerm: "tag:linux) OR (author:alice", expected: []int{0, 1, 2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filterer := NewFilterer(tc.searchTerm, config.Config{})
+			filterer.Store = s
+			matches := filterer.Filter(targets)
+
+			var indexes []int
+			for _, m := range matches {
+				indexes = append(indexes, m.Index)
+			}
+			test.Equal(t, fmt.Sprint(tc.expected), fmt.Sprint(indexes), "wrong matches")
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+// Format is how CLI commands print their output
+type Format string
+
+const (
+	FormatText  Format = "text"
+	FormatJSON  Format = "json"
+	FormatJSONL Format = "jsonl"
+	FormatCSV   Format = "csv"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON, FormatJSONL, FormatCSV:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("unknown format %q, expected text, json, jsonl or csv", s)
+}
+
+// ItemRecord is the machine readable form of a store.Item
+type ItemRecord struct {
+	ID          int        `json:"id"`
+	FeedURL     string     `json:"feedUrl"`
+	FeedName    string     `json:"feedName,omitempty"`
+	Title       string     `json:"title"`
+	Link        string     `json:"link"`
+	Author      string     `json:"author,omitempty"`
+	Read        bool       `json:"read"`
+	Favourite   bool       `json:"favourite"`
+	PublishedAt *time.Time `json:"publishedAt,omitempty"`
+	ReadAt      *time.Time `json:"readAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	Labels      []string   `json:"labels,omitempty"`
+	DuplicateOf int        `json:"duplicateOf,omitempty"`
+}
+
+// ErrorRecord is a feed that failed to fetch
+type ErrorRecord struct {
+	FeedURL string `json:"feedUrl"`
+	Error   string `json:"error"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func toItemRecord(i store.Item) ItemRecord {
+	return ItemRecord{
+		ID:          i.ID,
+		FeedURL:     i.FeedURL,
+		FeedName:    i.FeedName,
+		Title:       i.Title,
+		Link:        i.Link,
+		Author:      i.Author,
+		Read:        i.Read(),
+		Favourite:   i.Favourite,
+		PublishedAt: optionalTime(i.PublishedAt),
+		ReadAt:      optionalTime(i.ReadAt),
+		CreatedAt:   i.CreatedAt,
+		UpdatedAt:   i.UpdatedAt,
+		Labels:      i.Labels,
+		DuplicateOf: i.DuplicateOf,
+	}
+}
+
+func toItemRecords(its []store.Item) []ItemRecord {
+	records := make([]ItemRecord, 0, len(its))
+	for _, i := range its {
+		records = append(records, toItemRecord(i))
+	}
+	return records
+}
+
+var itemCSVHeader = []string{"id", "feedUrl", "feedName", "title", "link", "author", "read", "favourite", "publishedAt", "readAt", "createdAt", "updatedAt", "labels"}
+
+func (r ItemRecord) csvRow() []string {
+	return []string{
+		strconv.Itoa(r.ID),
+		r.FeedURL,
+		r.FeedName,
+		r.Title,
+		r.Link,
+		r.Author,
+		strconv.FormatBool(r.Read),
+		strconv.FormatBool(r.Favourite),
+		formatOptionalTime(r.PublishedAt),
+		formatOptionalTime(r.ReadAt),
+		r.CreatedAt.Format(time.RFC3339),
+		r.UpdatedAt.Format(time.RFC3339),
+		strings.Join(r.Labels, ","),
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeJSONL[T any](w io.Writer, records []T) error {
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		err := enc.Encode(r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write(header)
+	if err != nil {
+		return err
+	}
+
+	err = cw.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
+
+func writeItems(w io.Writer, format Format, its []store.Item) error {
+	records := toItemRecords(its)
+
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, records)
+	case FormatJSONL:
+		return writeJSONL(w, records)
+	case FormatCSV:
+		rows := make([][]string, 0, len(records))
+		for _, r := range records {
+			rows = append(rows, r.csvRow())
+		}
+		return writeCSV(w, itemCSVHeader, rows)
+	}
+
+	return fmt.Errorf("writeItems: unsupported format %q", format)
+}
+
+// writeRefresh writes the fetched items and fetch errors. json is a single
+// object, jsonl and csv tag each record with its type.
+func writeRefresh(w io.Writer, format Format, its []store.Item, errorItems []ErrorItem) error {
+	errs := make([]ErrorRecord, 0, len(errorItems))
+	for _, e := range errorItems {
+		errs = append(errs, ErrorRecord{FeedURL: e.FeedURL, Error: e.Err.Error()})
+	}
+
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, struct {
+			Items  []ItemRecord  `json:"items"`
+			Errors []ErrorRecord `json:"errors"`
+		}{toItemRecords(its), errs})
+
+	case FormatJSONL:
+		type typedItem struct {
+			Type string `json:"type"`
+			ItemRecord
+		}
+		type typedError struct {
+			Type string `json:"type"`
+			ErrorRecord
+		}
+
+		var lines []any
+		for _, r := range toItemRecords(its) {
+			lines = append(lines, typedItem{"item", r})
+		}
+		for _, e := range errs {
+			lines = append(lines, typedError{"error", e})
+		}
+		return writeJSONL(w, lines)
+
+	case FormatCSV:
+		header := append([]string{"type"}, itemCSVHeader...)
+		header = append(header, "error")
+
+		var rows [][]string
+		for _, r := range toItemRecords(its) {
+			row := append([]string{"item"}, r.csvRow()...)
+			rows = append(rows, append(row, ""))
+		}
+		for _, e := range errs {
+			row := make([]string, len(header))
+			row[0] = "error"
+			row[2] = e.FeedURL
+			row[len(row)-1] = e.Error
+			rows = append(rows, row)
+		}
+		return writeCSV(w, header, rows)
+	}
+
+	return fmt.Errorf("writeRefresh: unsupported format %q", format)
+}
+
+// PrintUnread prints the number of unread items
+func (c Commands) PrintUnread(format Format) error {
+	count, err := c.store.CountUnread()
+	if err != nil {
+		return fmt.Errorf("commands PrintUnread: %w", err)
+	}
+
+	switch format {
+	case FormatJSON, FormatJSONL:
+		err = json.NewEncoder(os.Stdout).Encode(map[string]int{"unread": count})
+	case FormatCSV:
+		err = writeCSV(os.Stdout, []string{"unread"}, [][]string{{strconv.Itoa(count)}})
+	default:
+		_, err = fmt.Printf("%d\n", count)
+	}
+	if err != nil {
+		return fmt.Errorf("commands PrintUnread: %w", err)
+	}
+
+	return nil
+}
+
+// writeConfig writes the config with the same keys as the config file. csv
+// only has room for the feeds.
+func writeConfig(w io.Writer, format Format, v any) error {
+	raw, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var m map[string]any
+	err = yaml.Unmarshal(raw, &m)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, m)
+	case FormatJSONL:
+		return json.NewEncoder(w).Encode(m)
+	case FormatCSV:
+		var file struct {
+			Feeds []struct {
+				URL  string   `yaml:"url"`
+				Name string   `yaml:"name"`
+				Tags []string `yaml:"tags"`
+			} `yaml:"feeds"`
+		}
+		err = yaml.Unmarshal(raw, &file)
+		if err != nil {
+			return err
+		}
+
+		var rows [][]string
+		for _, f := range file.Feeds {
+			rows = append(rows, []string{f.URL, f.Name, strings.Join(f.Tags, ",")})
+		}
+		return writeCSV(w, []string{"url", "name", "tags"}, rows)
+	}
+
+	return fmt.Errorf("writeConfig: unsupported format %q", format)
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+var formatItems = []store.Item{
+	{ID: 1, FeedURL: "https://a.com/feed", Title: "One, with a comma", Link: "https://a.com/1", PublishedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+	{ID: 2, FeedURL: "https://a.com/feed", Title: "Two", Link: "https://a.com/2", Favourite: true, ReadAt: time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC)},
+}
+
+func TestWriteItems(t *testing.T) {
+	var buf bytes.Buffer
+	test.HandleError(t, writeItems(&buf, FormatJSONL, formatItems))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	test.Equal(t, 2, len(lines), "expected a line per item")
+
+	var r ItemRecord
+	test.HandleError(t, json.Unmarshal([]byte(lines[1]), &r))
+	test.Equal(t, 2, r.ID, "wrong id")
+	test.Equal(t, true, r.Read, "expected read")
+	test.Equal(t, true, r.Favourite, "expected favourite")
+	test.Equal(t, true, r.PublishedAt == nil, "expected no published date")
+
+	buf.Reset()
+	test.HandleError(t, writeItems(&buf, FormatCSV, formatItems))
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	test.HandleError(t, err)
+	test.Equal(t, 3, len(rows), "expected header and a row per item")
+	test.Equal(t, "title", rows[0][3], "wrong header")
+	test.Equal(t, "One, with a comma", rows[1][3], "wrong title")
+	test.Equal(t, "2024-01-02T03:04:05Z", rows[1][8], "wrong published date")
+}
+
+func TestWriteRefresh(t *testing.T) {
+	errs := []ErrorItem{{FeedURL: "https://b.com/feed", Err: errors.New("boom")}}
+
+	var buf bytes.Buffer
+	test.HandleError(t, writeRefresh(&buf, FormatJSON, formatItems, errs))
+
+	var res struct {
+		Items  []ItemRecord  `json:"items"`
+		Errors []ErrorRecord `json:"errors"`
+	}
+	test.HandleError(t, json.Unmarshal(buf.Bytes(), &res))
+	test.Equal(t, 2, len(res.Items), "wrong number of items")
+	test.Equal(t, "boom", res.Errors[0].Error, "wrong error")
+
+	buf.Reset()
+	test.HandleError(t, writeRefresh(&buf, FormatJSONL, formatItems, errs))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	test.Equal(t, 3, len(lines), "expected a line per item and error")
+	test.Equal(t, `{"type":"error","feedUrl":"https://b.com/feed","error":"boom"}`, lines[2], "wrong error line")
+}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/guyfedwards/nom/v2/internal/readability"
+	"github.com/guyfedwards/nom/v2/internal/rss"
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+const (
+	// fullTextWorkers is how many articles are fetched at once after a refresh
+	fullTextWorkers = 4
+	// maxArticleSize stops huge pages from being read into memory
+	maxArticleSize = 5 << 20
+)
+
+// fullTextDone is sent to the TUI when an article fetched on demand is ready
+type fullTextDone struct {
+	id  int
+	err error
+}
+
+// FetchFullText fetches the page an item links to and stores the article
+// extracted from it
+func (c Commands) FetchFullText(ID int) error {
+	item, err := c.store.GetItemByID(ID)
+	if err != nil {
+		return fmt.Errorf("commands FetchFullText: %w", err)
+	}
+
+	content, err := c.extractArticle(item)
+	if err != nil {
+		return fmt.Errorf("commands FetchFullText: %w", err)
+	}
+
+	err = c.store.SetFullText(ID, content)
+	if err != nil {
+		return fmt.Errorf("commands FetchFullText: %w", err)
+	}
+
+	return nil
+}
+
+// fetchFullTexts fetches the articles of new items from feeds with fulltext
+// set. Failures are logged, leaving the summary from the feed.
+func (c Commands) fetchFullTexts(items []store.Item) {
+	var wanted []store.Item
+	for _, it := range items {
+		if !it.Hidden && it.Link != "" && c.feedFor(it.FeedURL).FullText {
+			wanted = append(wanted, it)
+		}
+	}
+
+	if len(wanted) == 0 {
+		return
+	}
+
+	type result struct {
+		id      int
+		content string
+	}
+
+	jobs := make(chan store.Item)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for range min(fullTextWorkers, len(wanted)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for it := range jobs {
+				content, err := c.extractArticle(it)
+				if err != nil {
+					log.Printf("[fulltext.go] fetchFullTexts: %s: %v", it.Link, err)
+					continue
+				}
+				results <- result{id: it.ID, content: content}
+			}
+		}()
+	}
+
+	go func() {
+		for _, it := range wanted {
+			jobs <- it
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	// the store is written from this goroutine only
+	for r := range results {
+		err := c.store.SetFullText(r.id, r.content)
+		if err != nil {
+			log.Printf("[fulltext.go] fetchFullTexts: %v", err)
+		}
+	}
+}
+
+// extractArticle fetches the page an item links to, with the http options of
+// its feed, and returns the HTML of its main content. The feed's credentials
+// are only sent if the page is on the feed's host.
+func (c Commands) extractArticle(it store.Item) (string, error) {
+	link := it.Link
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("extractArticle: not a web page: %q", link)
+	}
+
+	opts := c.config.LinkHTTPOptions(c.feedFor(it.FeedURL), link)
+
+	client, err := rss.NewClient(opts)
+	if err != nil {
+		return "", fmt.Errorf("extractArticle: %w", err)
+	}
+
+	req, err := rss.NewRequest(link, opts, c.config.Version)
+	if err != nil {
+		return "", fmt.Errorf("extractArticle: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("extractArticle: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("extractArticle: %s: %s", link, resp.Status)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "html") {
+		return "", fmt.Errorf("extractArticle: %s: not HTML: %s", link, ct)
+	}
+
+	// relative links resolve against where we ended up after redirects
+	article, err := readability.Extract(io.LimitReader(resp.Body, maxArticleSize), resp.Request.URL)
+	if err != nil {
+		return "", fmt.Errorf("extractArticle: %w", err)
+	}
+
+	return article.Content, nil
+}
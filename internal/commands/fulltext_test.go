@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+const fullTextPage = `<html><body>
+<nav><a href="/">Home</a></nav>
+<article>
+<p>The whole story is much longer than the summary, with details, quotes, and figures.</p>
+<p>It even mentions marmalade, which the summary in the feed never does, for testing.</p>
+</article>
+</body></html>`
+
+func TestFullText(t *testing.T) {
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/summaries", "/other":
+			fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>feed</title>
+<item><title>Story</title><link>%s/story%s</link><description>Just a summary.</description></item>
+</channel></rss>`, srvURL, r.URL.Path)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, fullTextPage)
+		}
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	cfg := &config.Config{Feeds: []config.Feed{
+		{URL: srv.URL + "/summaries", FullText: true},
+		{URL: srv.URL + "/other"},
+	}}
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+
+	_, _, err = c.fetchAllFeeds()
+	test.HandleError(t, err)
+
+	its, err := s.GetAllItems("asc")
+	test.HandleError(t, err)
+	test.Equal(t, 2, len(its), "expected an item per feed")
+
+	byFeed := map[string]store.Item{}
+	for _, it := range its {
+		it, err = s.GetItemByID(it.ID)
+		test.HandleError(t, err)
+		byFeed[strings.TrimPrefix(it.FeedURL, srv.URL)] = it
+	}
+
+	test.Equal(t, true, strings.Contains(byFeed["/summaries"].FullText, "marmalade"), "expected full text to be fetched")
+	test.Equal(t, false, strings.Contains(byFeed["/summaries"].FullText, "Home"), "expected page furniture to be dropped")
+	test.Equal(t, "", byFeed["/other"].FullText, "expected other feeds to be left alone")
+
+	found, err := s.Search("marmalade")
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(found), "expected full text to be searchable")
+
+	// on demand for a single article
+	test.HandleError(t, c.FetchFullText(byFeed["/other"].ID))
+	it, err := s.GetItemByID(byFeed["/other"].ID)
+	test.HandleError(t, err)
+	test.Equal(t, true, strings.Contains(it.FullText, "marmalade"), "expected full text to be fetched on demand")
+
+	out, err := glamouriseItem(it, config.DefaultTheme)
+	test.HandleError(t, err)
+	test.Equal(t, true, strings.Contains(out, "marmalade"), "expected full text to be rendered")
+}
+
+func TestFullTextCredentials(t *testing.T) {
+	var mu sync.Mutex
+	var articleHeaders http.Header
+	article := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		articleHeaders = r.Header.Clone()
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, fullTextPage)
+	}))
+	defer article.Close()
+
+	var feedAuth string
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		feedAuth = r.Header.Get("Authorization")
+		mu.Unlock()
+		fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>feed</title>
+<item><title>Story</title><link>%s/story</link></item>
+</channel></rss>`, article.URL)
+	}))
+	defer feed.Close()
+
+	cfg := &config.Config{Feeds: []config.Feed{{
+		URL:      feed.URL,
+		FullText: true,
+		HTTP: &config.HTTPOptions{
+			Auth:    &config.HTTPAuth{Token: config.Secret{Value: "intranet"}},
+			Cookies: map[string]config.Secret{"session": {Value: "abc"}},
+			Headers: map[string]config.Secret{"X-Api-Key": {Value: "key"}},
+		},
+	}}}
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+
+	_, _, err = c.fetchAllFeeds()
+	test.HandleError(t, err)
+
+	test.Equal(t, "Bearer intranet", feedAuth, "expected the feed to get its credentials")
+	test.Equal(t, true, articleHeaders != nil, "expected the article to be fetched")
+	test.Equal(t, "", articleHeaders.Get("Authorization"), "article on another host got the feed's auth")
+	test.Equal(t, "", articleHeaders.Get("Cookie"), "article on another host got the feed's cookies")
+	test.Equal(t, "", articleHeaders.Get("X-Api-Key"), "article on another host got the feed's headers")
+}
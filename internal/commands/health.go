@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+// fetchLogShown is the number of attempts shown for a single feed
+const fetchLogShown = 20
+
+type feedHealth struct {
+	feed config.Feed
+	meta store.FeedMeta
+}
+
+func (c Commands) recordFetch(entry store.FetchLog) {
+	err := c.store.RecordFetch(entry)
+	if err != nil {
+		log.Printf("[health.go] recordFetch: %v", err)
+	}
+}
+
+// failingFeeds returns feeds that failed at least threshold times in a row,
+// the longest failing first
+func (c Commands) failingFeeds(threshold int) ([]feedHealth, error) {
+	metas, err := c.store.GetAllFeedMeta()
+	if err != nil {
+		return nil, fmt.Errorf("failingFeeds: %w", err)
+	}
+
+	var failing []feedHealth
+	for _, f := range c.config.GetFeeds() {
+		m, ok := metas[f.URL]
+		if ok && m.Failures > 0 && m.Failures >= threshold {
+			failing = append(failing, feedHealth{feed: f, meta: m})
+		}
+	}
+
+	slices.SortStableFunc(failing, func(a, b feedHealth) int {
+		return b.meta.Failures - a.meta.Failures
+	})
+
+	return failing, nil
+}
+
+func formatFetchTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+func writeHealth(w io.Writer, failing []feedHealth) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FEED\tFAILURES\tLAST SUCCESS\tSTATUS\tERROR")
+
+	for _, h := range failing {
+		name := h.feed.Name
+		if name == "" {
+			name = h.feed.URL
+		}
+
+		status := "-"
+		if h.meta.LastStatus != 0 {
+			status = fmt.Sprint(h.meta.LastStatus)
+		}
+
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", name, h.meta.Failures, formatFetchTime(h.meta.LastSuccessAt), status, h.meta.LastError)
+	}
+
+	return tw.Flush()
+}
+
+// FeedsHealth prints feeds that have failed at least threshold times in a
+// row, or the recent fetch attempts for one feed when ref is set. The
+// configured threshold is used when threshold is 0.
+func (c Commands) FeedsHealth(ref string, threshold int) error {
+	if ref != "" {
+		return c.printFetchLog(ref)
+	}
+
+	if threshold <= 0 {
+		threshold = c.config.FailureThreshold
+	}
+
+	failing, err := c.failingFeeds(threshold)
+	if err != nil {
+		return fmt.Errorf("commands FeedsHealth: %w", err)
+	}
+
+	if len(failing) == 0 {
+		fmt.Println("no failing feeds")
+		return nil
+	}
+
+	return writeHealth(os.Stdout, failing)
+}
+
+func (c Commands) printFetchLog(ref string) error {
+	url := ref
+	for _, f := range c.config.GetFeeds() {
+		if f.Name == ref {
+			url = f.URL
+		}
+	}
+
+	logs, err := c.store.GetFetchLog(url, fetchLogShown)
+	if err != nil {
+		return fmt.Errorf("commands FeedsHealth: %w", err)
+	}
+
+	if len(logs) == 0 {
+		fmt.Printf("no fetches recorded for %s\n", ref)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FETCHED\tSTATUS\tDURATION\tNEW\tERROR")
+
+	for _, l := range logs {
+		status := "-"
+		if l.Status != 0 {
+			status = fmt.Sprint(l.Status)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", formatFetchTime(l.FetchedAt), status, l.Duration.Round(time.Millisecond), l.NewItems, strings.TrimSpace(l.Error))
+	}
+
+	return w.Flush()
+}
+
+var healthCloseKey = key.NewBinding(
+	key.WithKeys("esc", "q", "H"),
+	key.WithHelp("q/esc", "close"),
+)
+
+// healthReport is the content of the TUI panel listing failing feeds
+func (c Commands) healthReport() (string, error) {
+	failing, err := c.failingFeeds(c.config.FailureThreshold)
+	if err != nil {
+		return "", err
+	}
+
+	if len(failing) == 0 {
+		return fmt.Sprintf("No feeds have failed %d or more times in a row.\n", c.config.FailureThreshold), nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Feeds failing %d or more times in a row:\n\n", c.config.FailureThreshold)
+	err = writeHealth(&b, failing)
+	if err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+func updateHealth(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, ViewportKeyMap.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, healthCloseKey):
+			m.showHealth = false
+			return m, nil
+		}
+	}
+
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func healthView(m model) string {
+	return m.viewport.View() + "\n" + helpStyle.Render(m.help.ShortHelpView([]key.Binding{healthCloseKey}))
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+const healthFeed = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>ok</title>
+<item><title>one</title><link>https://example.com/1</link></item>
+<item><title>two</title><link>https://example.com/2</link></item>
+</channel></rss>`
+
+func TestFetchHealth(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, healthFeed)
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Feeds: []config.Feed{
+			{URL: srv.URL + "/ok"},
+			{URL: srv.URL + "/broken", Name: "Broken"},
+		},
+		// each failure should be recorded, not retried
+		Fetch: &config.FetchOptions{Retries: -1},
+	}
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+
+	for range 2 {
+		_, errs, err := c.fetchAllFeeds()
+		test.HandleError(t, err)
+		test.Equal(t, 1, len(errs), "expected one failing feed")
+	}
+
+	logs, err := s.GetFetchLog(srv.URL+"/ok", 10)
+	test.HandleError(t, err)
+	test.Equal(t, 2, len(logs), "expected both fetches logged")
+	test.Equal(t, 0, logs[0].NewItems, "second fetch should find nothing new")
+	test.Equal(t, 2, logs[1].NewItems, "first fetch should find two new items")
+	test.Equal(t, http.StatusOK, logs[0].Status, "wrong status")
+
+	logs, err = s.GetFetchLog(srv.URL+"/broken", 10)
+	test.HandleError(t, err)
+	test.Equal(t, http.StatusInternalServerError, logs[0].Status, "wrong status")
+	test.Equal(t, true, logs[0].Error != "", "expected error to be logged")
+
+	failing, err := c.failingFeeds(2)
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(failing), "expected broken feed to be failing")
+	test.Equal(t, "Broken", failing[0].feed.Name, "wrong failing feed")
+	test.Equal(t, 2, failing[0].meta.Failures, "wrong failure count")
+
+	failing, err = c.failingFeeds(3)
+	test.HandleError(t, err)
+	test.Equal(t, 0, len(failing), "expected threshold to be respected")
+
+	// a success resets the count
+	err = s.RecordFetch(store.FetchLog{FeedURL: srv.URL + "/broken", Status: http.StatusOK})
+	test.HandleError(t, err)
+
+	failing, err = c.failingFeeds(1)
+	test.HandleError(t, err)
+	test.Equal(t, 0, len(failing), "expected success to reset failures")
+}
+
+// failingStore fails to store the item with link fail
+type failingStore struct {
+	*store.SQLiteStore
+	fail string
+}
+
+func (s failingStore) UpsertItem(item *store.Item) (bool, error) {
+	if item.Link == s.fail {
+		return false, errors.New("disk full")
+	}
+	return s.SQLiteStore.UpsertItem(item)
+}
+
+func TestCacheHeadersAfterItems(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == "v1" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", "v1")
+		fmt.Fprint(w, healthFeed)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{Feeds: []config.Feed{{URL: srv.URL}}}
+	sqlite, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	s := failingStore{SQLiteStore: sqlite, fail: "https://example.com/2"}
+	_, _, err = New(cfg, s).fetchFeeds(cfg.Feeds, false)
+	test.HandleError(t, err)
+
+	metas, err := sqlite.GetAllFeedMeta()
+	test.HandleError(t, err)
+	test.Equal(t, "", metas[srv.URL].ETag, "etag shouldn't be saved while an item is missing")
+
+	s.fail = ""
+	_, _, err = New(cfg, s).fetchFeeds(cfg.Feeds, false)
+	test.HandleError(t, err)
+
+	items, err := sqlite.GetAllItems("asc")
+	test.HandleError(t, err)
+	test.Equal(t, 2, len(items), "missing item should be stored on the next fetch")
+
+	metas, err = sqlite.GetAllFeedMeta()
+	test.HandleError(t, err)
+	test.Equal(t, "v1", metas[srv.URL].ETag, "etag should be saved once every item is stored")
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"strings"
 )
 
 type OutlineType string
@@ -22,7 +23,8 @@ type OPML struct {
 }
 
 type Head struct {
-	Title string `xml:"title,omitempty"`
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
 }
 
 type Body struct {
@@ -36,6 +38,9 @@ type Outline struct {
 	Title    string      `xml:"title,attr,omitempty"`
 	Type     OutlineType `xml:"type,attr,omitempty"`
 	XMLUrl   *url.URL    `xml:"xmlUrl,attr,omitempty"`
+	// Category is a comma separated list of slash delimited categories,
+	// e.g. "/tech/go,/daily"
+	Category string `xml:"category,attr,omitempty"`
 }
 
 func (o *Outline) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
@@ -60,6 +65,8 @@ func (o *Outline) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 			o.Text = attr.Value
 		case "title":
 			o.Title = attr.Value
+		case "category":
+			o.Category = attr.Value
 		}
 	}
 	// Recursively decode child outlines
@@ -81,6 +88,18 @@ func (o *Outline) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	return nil
 }
 
+// Tags returns the categories of an outline as nom tags
+func (o Outline) Tags() []string {
+	var tags []string
+	for _, cat := range strings.Split(o.Category, ",") {
+		tag := strings.Trim(strings.TrimSpace(cat), tagSeparator)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 func parseOPML(input []byte) (*OPML, error) {
 	var opml OPML
 	err := xml.Unmarshal(input, &opml)
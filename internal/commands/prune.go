@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+// prunePolicy converts config.Retention for the store, ok is false when no
+// retention has been configured
+func (c Commands) prunePolicy() (p store.PrunePolicy, ok bool, err error) {
+	r := c.config.Retention
+	if r == nil {
+		return p, false, nil
+	}
+
+	maxAge, err := r.MaxAgeDuration()
+	if err != nil {
+		return p, false, err
+	}
+
+	if maxAge == 0 && r.MaxItems == 0 {
+		return p, false, nil
+	}
+
+	return store.PrunePolicy{
+		MaxAge:         maxAge,
+		MaxItems:       r.MaxItems,
+		KeepUnread:     r.KeepUnread,
+		KeepFavourites: r.KeepFavourites,
+	}, true, nil
+}
+
+// tooOldToKeep reports whether a new item published at t would be pruned by
+// age straight away, which is only when unread items aren't kept. Those items
+// are not stored at all.
+func (c Commands) tooOldToKeep(t time.Time) bool {
+	if t.IsZero() || c.config.Retention == nil || c.config.Retention.KeepUnread {
+		return false
+	}
+
+	maxAge, err := c.config.Retention.MaxAgeDuration()
+	if err != nil || maxAge == 0 {
+		return false
+	}
+
+	return time.Since(t) > maxAge
+}
+
+// minPrunedMemory is the shortest time pruned items are remembered, as feeds
+// often list items for longer than a short maxAge
+const minPrunedMemory = 30 * 24 * time.Hour
+
+// prunedMemory is how long pruned items are remembered to stop them being
+// stored again: the retention window, but at least minPrunedMemory
+func prunedMemory(p store.PrunePolicy) time.Duration {
+	return max(p.MaxAge, minPrunedMemory)
+}
+
+// applyRetention deletes items according to the configured retention policy
+// and returns what was deleted
+func (c Commands) applyRetention(dryRun bool) ([]store.Item, error) {
+	p, ok, err := c.prunePolicy()
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	items, err := c.store.GetPrunableItems(p)
+	if err != nil {
+		return nil, fmt.Errorf("applyRetention: %w", err)
+	}
+
+	if dryRun {
+		return items, nil
+	}
+
+	ids := make([]int, len(items))
+	for i, it := range items {
+		ids[i] = it.ID
+	}
+
+	err = c.store.DeleteItems(ids)
+	if err != nil {
+		return nil, fmt.Errorf("applyRetention: %w", err)
+	}
+
+	err = c.store.ForgetPruned(time.Now().Add(-prunedMemory(p)))
+	if err != nil {
+		return nil, fmt.Errorf("applyRetention: %w", err)
+	}
+
+	return items, nil
+}
+
+// Prune applies the retention policy, or with dryRun lists what it would delete
+func (c Commands) Prune(dryRun bool) error {
+	if _, ok, err := c.prunePolicy(); err != nil {
+		return fmt.Errorf("commands Prune: %w", err)
+	} else if !ok {
+		fmt.Println("no retention policy configured, see retention in the README")
+		return nil
+	}
+
+	items, err := c.applyRetention(dryRun)
+	if err != nil {
+		return fmt.Errorf("commands Prune: %w", err)
+	}
+
+	if !dryRun {
+		fmt.Printf("deleted %d items\n", len(items))
+		return nil
+	}
+
+	names := map[string]string{}
+	for _, f := range c.config.GetFeeds() {
+		names[f.URL] = f.Name
+	}
+
+	counts := map[string]int{}
+	var order []string
+	for _, it := range items {
+		if counts[it.FeedURL] == 0 {
+			order = append(order, it.FeedURL)
+		}
+		counts[it.FeedURL]++
+	}
+
+	fmt.Printf("would delete %d items\n", len(items))
+	for _, url := range order {
+		name := names[url]
+		if name == "" {
+			name = url
+		}
+		fmt.Printf("  %5d  %s\n", counts[url], name)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+func TestApplyRetention(t *testing.T) {
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	// five read items per feed, a day apart, plus an unread and a favourite
+	// item that are the oldest in feed a
+	for _, feed := range []string{"a", "b"} {
+		for d := 0; d < 5; d++ {
+			it := store.Item{FeedURL: feed, Link: fmt.Sprintf("%s/%d", feed, d), PublishedAt: time.Now().Add(-time.Duration(d) * 24 * time.Hour)}
+			_, err = s.UpsertItem(&it)
+			test.HandleError(t, err)
+			test.HandleError(t, s.ToggleRead(it.ID))
+		}
+	}
+
+	unread := store.Item{FeedURL: "a", Link: "a/unread", PublishedAt: time.Now().Add(-10 * 24 * time.Hour)}
+	_, err = s.UpsertItem(&unread)
+	test.HandleError(t, err)
+
+	fav := store.Item{FeedURL: "a", Link: "a/fav", PublishedAt: time.Now().Add(-11 * 24 * time.Hour)}
+	_, err = s.UpsertItem(&fav)
+	test.HandleError(t, err)
+	test.HandleError(t, s.ToggleRead(fav.ID))
+	test.HandleError(t, s.ToggleFavourite(fav.ID))
+
+	testCases := []struct {
+		name      string
+		retention config.Retention
+		expected  int
+	}{
+		{name: "max items", retention: config.Retention{MaxItems: 3, KeepUnread: true, KeepFavourites: true}, expected: 4},
+		{name: "max age", retention: config.Retention{MaxAge: "60h", KeepUnread: true, KeepFavourites: true}, expected: 4},
+		{name: "include unread", retention: config.Retention{MaxAge: "60h", KeepFavourites: true}, expected: 5},
+		{name: "include favourites", retention: config.Retention{MaxAge: "60h"}, expected: 6},
+		{name: "both limits", retention: config.Retention{MaxAge: "84h", MaxItems: 4, KeepUnread: true, KeepFavourites: true}, expected: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New(&config.Config{Retention: &tc.retention}, s)
+
+			items, err := c.applyRetention(true)
+			test.HandleError(t, err)
+			test.Equal(t, tc.expected, len(items), "wrong number of prunable items")
+		})
+	}
+
+	c := New(&config.Config{Retention: &config.Retention{MaxItems: 1}}, s)
+	_, err = c.applyRetention(false)
+	test.HandleError(t, err)
+
+	left, err := s.GetAllItems("asc")
+	test.HandleError(t, err)
+	test.Equal(t, 2, len(left), "expected one item per feed to be kept")
+}
+
+const pruneFeed = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>prune</title>
+<item><title>new</title><link>https://example.com/new</link><pubDate>Fri, 02 Jan 2026 10:00:00 +0000</pubDate></item>
+<item><title>mid</title><link>https://example.com/mid</link><pubDate>Fri, 02 Jan 2026 09:00:00 +0000</pubDate></item>
+<item><title>old</title><link>https://example.com/old</link><pubDate>Fri, 02 Jan 2026 08:00:00 +0000</pubDate></item>
+</channel></rss>`
+
+func TestPrunedItemsStayGone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pruneFeed)
+	}))
+	defer srv.Close()
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	cfg := &config.Config{
+		Feeds:     []config.Feed{{URL: srv.URL}},
+		Retention: &config.Retention{MaxItems: 1, KeepUnread: true},
+	}
+	c := New(cfg, s)
+
+	_, _, err = c.fetchFeeds(cfg.Feeds, false)
+	test.HandleError(t, err)
+	test.HandleError(t, s.MarkAllRead())
+
+	// the read items past maxItems are pruned after this fetch, and the next
+	// one shouldn't bring them back as unread
+	for range 2 {
+		_, _, err = c.fetchFeeds(cfg.Feeds, false)
+		test.HandleError(t, err)
+	}
+
+	left, err := s.GetAllItems("asc")
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(left), "pruned items shouldn't be stored again")
+	test.Equal(t, "new", left[0].Title, "wrong item kept")
+}
+
+func TestRetentionTimezones(t *testing.T) {
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	// east was published an hour before utc, though its local time is later
+	published := map[string]time.Time{
+		"utc":  time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC),
+		"east": time.Date(2026, 1, 2, 11, 0, 0, 0, time.FixedZone("", 2*60*60)),
+	}
+	ids := map[string]int{}
+	for title, at := range published {
+		it := store.Item{FeedURL: "a", Title: title, Link: "a/" + title, PublishedAt: at}
+		_, err = s.UpsertItem(&it)
+		test.HandleError(t, err)
+		ids[title] = it.ID
+	}
+
+	c := New(&config.Config{Retention: &config.Retention{MaxItems: 1}}, s)
+	items, err := c.applyRetention(true)
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(items), "wrong number of prunable items")
+	test.Equal(t, ids["east"], items[0].ID, "the older item should be pruned")
+}
+
+func TestTooOldToKeep(t *testing.T) {
+	old := time.Now().Add(-30 * 24 * time.Hour)
+
+	c := New(&config.Config{Retention: &config.Retention{MaxAge: "7d", KeepUnread: true}}, nil)
+	test.Equal(t, false, c.tooOldToKeep(old), "new items are unread, so should be kept")
+
+	c = New(&config.Config{Retention: &config.Retention{MaxAge: "7d"}}, nil)
+	test.Equal(t, true, c.tooOldToKeep(old), "expected old item to be skipped")
+	test.Equal(t, false, c.tooOldToKeep(time.Now()), "expected recent item to be kept")
+}
+
+func TestForgetPruned(t *testing.T) {
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	it := store.Item{FeedURL: "a", Link: "a/1"}
+	_, err = s.UpsertItem(&it)
+	test.HandleError(t, err)
+	test.HandleError(t, s.DeleteItems([]int{it.ID}))
+
+	test.HandleError(t, s.ForgetPruned(time.Now().Add(-time.Hour)))
+	pruned, err := s.WasPruned(&it)
+	test.HandleError(t, err)
+	test.Equal(t, true, pruned, "recently pruned item should be remembered")
+
+	test.HandleError(t, s.ForgetPruned(time.Now().Add(time.Minute)))
+	pruned, err = s.WasPruned(&it)
+	test.HandleError(t, err)
+	test.Equal(t, false, pruned, "expected pruned item to be forgotten")
+}
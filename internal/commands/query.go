@@ -0,0 +1,552 @@
+package commands
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/sahilm/fuzzy"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+const queryDateLayout = "2006-01-02"
+
+// queryFields maps each qualifier alias to its field
+var queryFields = map[string]string{
+	"feedname": "feed",
+	"feed":     "feed",
+	"f":        "feed",
+	"tag":      "tag",
+	"t":        "tag",
+	"body":     "body",
+	"b":        "body",
+	"author":   "author",
+	"a":        "author",
+	"title":    "title",
+	"label":    "label",
+	"l":        "label",
+	"is":       "is",
+	"after":    "after",
+	"before":   "before",
+	"age":      "age",
+}
+
+// itemFields are the fields that need the stored item rather than what the
+// list shows
+var itemFields = map[string]bool{"author": true, "label": true, "is": true, "after": true, "before": true, "age": true}
+
+// Query is a parsed filter, e.g. `tag:security -feed:hn age:<7d`. Terms next
+// to each other must all match, OR matches either side, NOT or a leading `-`
+// negates, and parentheses group.
+type Query struct {
+	root queryNode
+	// Texts are the plain words that aren't negated, used to rank matches
+	Texts []string
+	// Bodies are the `body:` terms, which are looked up in the search index
+	Bodies []string
+	// NeedsItem is set when a term matches on fields only the store has
+	NeedsItem bool
+}
+
+// queryContext is what terms are evaluated with
+type queryContext struct {
+	now             time.Time
+	includeFeedName bool
+	// bodies holds the IDs of the items matching each `body:` term
+	bodies map[string]map[int]bool
+}
+
+type queryNode interface {
+	match(ctx *queryContext, it store.Item, negated bool) bool
+}
+
+type andNode []queryNode
+
+func (n andNode) match(ctx *queryContext, it store.Item, negated bool) bool {
+	for _, c := range n {
+		if !c.match(ctx, it, negated) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode []queryNode
+
+func (n orNode) match(ctx *queryContext, it store.Item, negated bool) bool {
+	for _, c := range n {
+		if c.match(ctx, it, negated) {
+			return true
+		}
+	}
+	return false
+}
+
+type notNode struct {
+	node queryNode
+}
+
+func (n notNode) match(ctx *queryContext, it store.Item, negated bool) bool {
+	return !n.node.match(ctx, it, !negated)
+}
+
+type termNode struct {
+	field string
+	value string
+	// date and age hold the parsed values of date and age terms
+	date time.Time
+	age  time.Duration
+	cmp  string
+}
+
+func (n termNode) match(ctx *queryContext, it store.Item, negated bool) bool {
+	switch n.field {
+	case "":
+		target := it.Title
+		if ctx.includeFeedName {
+			target = it.FeedName + " " + it.Title
+		}
+
+		// negated words match as substrings, excluding everything a word
+		// fuzzily matches would leave very little
+		if negated {
+			return containsFold(target, n.value)
+		}
+		return len(fuzzy.Find(n.value, []string{target})) > 0
+
+	case "title":
+		return containsFold(it.Title, n.value)
+
+	case "feed":
+		return containsFold(it.FeedName, n.value) || containsFold(it.FeedURL, n.value)
+
+	case "tag":
+		for _, t := range it.Tags {
+			t = strings.ToLower(t)
+			if t == n.value || strings.HasPrefix(t, n.value+"/") {
+				return true
+			}
+		}
+		return false
+
+	case "body":
+		return ctx.bodies[n.value][it.ID]
+
+	case "author":
+		return containsFold(it.Author, n.value)
+
+	case "label":
+		for _, l := range it.Labels {
+			if strings.EqualFold(l, n.value) {
+				return true
+			}
+		}
+		return false
+
+	case "is":
+		switch n.value {
+		case "read":
+			return it.Read()
+		case "unread":
+			return !it.Read()
+		default:
+			return it.Favourite
+		}
+
+	case "after":
+		return !itemTime(it).Before(n.date)
+
+	case "before":
+		return itemTime(it).Before(n.date)
+
+	case "age":
+		age := ctx.now.Sub(itemTime(it))
+		switch n.cmp {
+		case ">":
+			return age > n.age
+		case ">=":
+			return age >= n.age
+		case "<=":
+			return age <= n.age
+		default:
+			return age < n.age
+		}
+	}
+
+	return true
+}
+
+// itemTime is when an item was published, or first seen if the feed doesn't
+// say
+func itemTime(it store.Item) time.Time {
+	if it.PublishedAt.IsZero() {
+		return it.CreatedAt
+	}
+	return it.PublishedAt
+}
+
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+type queryTokenKind int
+
+const (
+	tokenTerm queryTokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	term *termNode
+}
+
+// lexQuery splits a query into tokens. Terms that can't be used yet, such as
+// an unclosed quote or `age:<` while it is being typed, are dropped so the
+// list keeps filtering on the rest.
+func lexQuery(s string) []queryToken {
+	var tokens []queryToken
+	rs := []rune(s)
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: tokenOpen})
+			i++
+			continue
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: tokenClose})
+			i++
+			continue
+		}
+
+		negated := false
+		if r == '-' && i+1 < len(rs) && !unicode.IsSpace(rs[i+1]) && rs[i+1] != ')' {
+			negated = true
+			i++
+
+			if rs[i] == '(' {
+				tokens = append(tokens, queryToken{kind: tokenNot})
+				continue
+			}
+		}
+
+		field := ""
+		if colon := fieldEnd(rs[i:]); colon > 0 {
+			if f, ok := queryFields[strings.ToLower(string(rs[i:i+colon]))]; ok {
+				field = f
+				i += colon + 1
+			}
+		}
+
+		value, quoted, complete, next := readQueryWord(rs, i)
+		i = next
+
+		if field == "" && !negated && !quoted {
+			switch value {
+			case "AND":
+				tokens = append(tokens, queryToken{kind: tokenAnd})
+				continue
+			case "OR":
+				tokens = append(tokens, queryToken{kind: tokenOr})
+				continue
+			case "NOT":
+				tokens = append(tokens, queryToken{kind: tokenNot})
+				continue
+			}
+		}
+
+		if !complete || value == "" {
+			continue
+		}
+
+		term, ok := newTermNode(field, value)
+		if !ok {
+			continue
+		}
+
+		if negated {
+			tokens = append(tokens, queryToken{kind: tokenNot})
+		}
+		tokens = append(tokens, queryToken{kind: tokenTerm, term: term})
+	}
+
+	return tokens
+}
+
+// fieldEnd returns the index of the colon ending a leading qualifier, or -1
+func fieldEnd(rs []rune) int {
+	for i, r := range rs {
+		if r == ':' {
+			return i
+		}
+		if !unicode.IsLetter(r) {
+			return -1
+		}
+	}
+	return -1
+}
+
+// readQueryWord reads a word starting at i, which can be quoted with single
+// or double quotes or have backslash escaped spaces. complete is false for
+// an unclosed quote. Quotes inside a word, as in "don't", are kept.
+func readQueryWord(rs []rune, i int) (value string, quoted bool, complete bool, next int) {
+	var b strings.Builder
+	start := i
+
+	for i < len(rs) {
+		r := rs[i]
+
+		switch {
+		case unicode.IsSpace(r) || r == '(' || r == ')':
+			return b.String(), quoted, true, i
+
+		case r == '\\' && i+1 < len(rs) && rs[i+1] == ' ':
+			b.WriteRune(' ')
+			i += 2
+
+		case (r == '"' || r == '\'') && i == start:
+			quoted = true
+			end := -1
+			for j := i + 1; j < len(rs); j++ {
+				if rs[j] == r {
+					end = j
+					break
+				}
+			}
+			if end < 0 {
+				return "", quoted, false, len(rs)
+			}
+			b.WriteString(string(rs[i+1 : end]))
+			i = end + 1
+
+		default:
+			b.WriteRune(r)
+			i++
+		}
+	}
+
+	return b.String(), quoted, true, i
+}
+
+func newTermNode(field string, value string) (*termNode, bool) {
+	n := &termNode{field: field, value: value}
+
+	switch field {
+	case "":
+		return n, true
+
+	case "feed", "tag", "body", "author", "title", "label":
+		n.value = strings.ToLower(value)
+		return n, true
+
+	case "is":
+		n.value = strings.ToLower(value)
+		switch n.value {
+		case "read", "unread":
+			return n, true
+		case "fav", "favourite", "favorite", "starred", "saved":
+			n.value = "fav"
+			return n, true
+		}
+		return nil, false
+
+	case "after", "before":
+		d, err := time.ParseInLocation(queryDateLayout, value, time.Local)
+		if err != nil {
+			return nil, false
+		}
+		n.date = d
+		return n, true
+
+	case "age":
+		for _, cmp := range []string{"<=", ">=", "<", ">"} {
+			if strings.HasPrefix(value, cmp) {
+				n.cmp = cmp
+				value = strings.TrimPrefix(value, cmp)
+				break
+			}
+		}
+
+		d, err := config.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, false
+		}
+		n.age = d
+		return n, true
+	}
+
+	return nil, false
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+	// depth is the number of open parentheses
+	depth int
+	query *Query
+}
+
+// ParseQuery parses a filter query. It never fails: anything it can't make
+// sense of is left out, so a query can be evaluated while it is typed.
+func ParseQuery(s string) *Query {
+	p := &queryParser{tokens: lexQuery(s), query: &Query{}}
+
+	p.query.root = p.parseOr(false)
+
+	return p.query
+}
+
+func (p *queryParser) peek() (queryTokenKind, bool) {
+	if p.pos >= len(p.tokens) {
+		return 0, false
+	}
+	return p.tokens[p.pos].kind, true
+}
+
+func (p *queryParser) parseOr(negated bool) queryNode {
+	var nodes orNode
+
+	for {
+		if n := p.parseAnd(negated); n != nil {
+			nodes = append(nodes, n)
+		}
+
+		kind, ok := p.peek()
+		if !ok || kind != tokenOr {
+			break
+		}
+		p.pos++
+	}
+
+	switch len(nodes) {
+	case 0:
+		return nil
+	case 1:
+		return nodes[0]
+	}
+	return nodes
+}
+
+func (p *queryParser) parseAnd(negated bool) queryNode {
+	var nodes andNode
+
+	for {
+		kind, ok := p.peek()
+		if !ok || kind == tokenOr || (kind == tokenClose && p.depth > 0) {
+			break
+		}
+
+		// a stray closing parenthesis is skipped
+		if kind == tokenAnd || kind == tokenClose {
+			p.pos++
+			continue
+		}
+
+		if n := p.parseUnary(negated); n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+
+	switch len(nodes) {
+	case 0:
+		return nil
+	case 1:
+		return nodes[0]
+	}
+	return nodes
+}
+
+func (p *queryParser) parseUnary(negated bool) queryNode {
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch tok.kind {
+	case tokenNot:
+		if kind, ok := p.peek(); !ok || kind == tokenOr || kind == tokenClose || kind == tokenAnd {
+			return nil
+		}
+
+		n := p.parseUnary(!negated)
+		if n == nil {
+			return nil
+		}
+		return notNode{n}
+
+	case tokenOpen:
+		p.depth++
+		defer func() { p.depth-- }()
+
+		n := p.parseOr(negated)
+		// an unclosed group runs to the end of the query
+		if kind, ok := p.peek(); ok && kind == tokenClose {
+			p.pos++
+		}
+		return n
+
+	case tokenTerm:
+		switch {
+		case tok.term.field == "" && !negated:
+			p.query.Texts = append(p.query.Texts, tok.term.value)
+		case tok.term.field == "body":
+			p.query.Bodies = append(p.query.Bodies, tok.term.value)
+		}
+
+		if itemFields[tok.term.field] {
+			p.query.NeedsItem = true
+		}
+
+		return *tok.term
+	}
+
+	return nil
+}
+
+// Match reports whether the item matches the query
+func (q *Query) Match(ctx *queryContext, it store.Item) bool {
+	if q.root == nil {
+		return true
+	}
+	return q.root.match(ctx, it, false)
+}
+
+// newQueryContext looks up the query's `body:` terms in the search index. With
+// no store they match nothing.
+func (q *Query) newQueryContext(cfg config.Config, s store.Store) *queryContext {
+	ctx := &queryContext{
+		now:             time.Now(),
+		includeFeedName: cfg.Filtering.DefaultIncludeFeedName,
+		bodies:          map[string]map[int]bool{},
+	}
+
+	for _, body := range q.Bodies {
+		ids := map[int]bool{}
+		ctx.bodies[body] = ids
+
+		if s == nil {
+			continue
+		}
+
+		items, err := s.Search(store.QuoteSearchTerm(body))
+		if err != nil {
+			continue
+		}
+
+		for _, it := range items {
+			ids[it.ID] = true
+		}
+	}
+
+	return ctx
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+var (
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	diffSameStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	diffHeaderStyle  = lipgloss.NewStyle().Bold(true)
+)
+
+type diffOp int
+
+const (
+	diffSame diffOp = iota
+	diffAdded
+	diffRemoved
+)
+
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// diffLines compares two versions line by line, using the longest common
+// subsequence to find what stayed the same
+func diffLines(before []string, after []string) []diffLine {
+	// lcs[i][j] is the length of the common subsequence of before[i:] and after[j:]
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(before) && j < len(after) {
+		switch {
+		case before[i] == after[j]:
+			lines = append(lines, diffLine{diffSame, before[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{diffRemoved, before[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{diffAdded, after[j]})
+			j++
+		}
+	}
+	for ; i < len(before); i++ {
+		lines = append(lines, diffLine{diffRemoved, before[i]})
+	}
+	for ; j < len(after); j++ {
+		lines = append(lines, diffLine{diffAdded, after[j]})
+	}
+
+	return lines
+}
+
+func articleLines(title string, content string) []string {
+	mdown := "# " + title + "\n\n" + htmlToMd(content)
+	return strings.Split(strings.TrimSpace(mdown), "\n")
+}
+
+// GetArticleDiff renders what changed in an article since its last revision,
+// wrapped to width
+func (c Commands) GetArticleDiff(ID int, width int) (string, error) {
+	article, err := c.store.GetItemByID(ID)
+	if err != nil {
+		return "", fmt.Errorf("[revisions.go] GetArticleDiff: %w", err)
+	}
+
+	revs, err := c.store.GetRevisions(ID)
+	if err != nil {
+		return "", fmt.Errorf("[revisions.go] GetArticleDiff: %w", err)
+	}
+
+	if len(revs) == 0 {
+		return "\n  No earlier versions of this article.\n", nil
+	}
+
+	return renderDiff(article, revs[0], len(revs), width), nil
+}
+
+func renderDiff(article store.Item, rev store.Revision, count int, width int) string {
+	var b strings.Builder
+
+	header := fmt.Sprintf("Changes since %s", rev.RevisedAt.Format("2006-01-02 15:04"))
+	if count > 1 {
+		header = fmt.Sprintf("%s (%d revisions)", header, count)
+	}
+	b.WriteString(diffHeaderStyle.Render(header))
+	b.WriteString("\n\n")
+
+	style := lipgloss.NewStyle()
+	if width > 2 {
+		style = style.Width(width - 2)
+	}
+
+	for _, l := range diffLines(articleLines(rev.Title, rev.Content), articleLines(article.Title, article.Content)) {
+		prefix, lineStyle := "  ", diffSameStyle
+		switch l.op {
+		case diffAdded:
+			prefix, lineStyle = "+ ", diffAddedStyle
+		case diffRemoved:
+			prefix, lineStyle = "- ", diffRemovedStyle
+		}
+
+		for _, wrapped := range strings.Split(style.Render(l.text), "\n") {
+			b.WriteString(lineStyle.Render(prefix + strings.TrimRight(wrapped, " ")))
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+const revisionsFeed = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>advisories</title>
+<item><title>%s</title><guid>urn:advisory:2024-0001</guid><link>%s</link><description>%s</description></item>
+</channel></rss>`
+
+func TestRevisions(t *testing.T) {
+	title, link, content := "Advisory 1", "https://example.com/a/1", "<p>Affects 1.0</p><p>Upgrade soon.</p>"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, revisionsFeed, title, link, content)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{Feeds: []config.Feed{{URL: srv.URL}}}
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+
+	_, _, err = c.fetchAllFeeds()
+	test.HandleError(t, err)
+
+	its, err := s.GetAllItems("asc")
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(its), "expected one item")
+	test.Equal(t, false, its[0].Updated(), "expected new item not to be updated")
+	test.HandleError(t, s.ToggleRead(its[0].ID))
+
+	// a refresh with nothing changed keeps no revision
+	_, _, err = c.fetchAllFeeds()
+	test.HandleError(t, err)
+	revs, err := s.GetRevisions(its[0].ID)
+	test.HandleError(t, err)
+	test.Equal(t, 0, len(revs), "expected no revisions")
+
+	// the publisher moves the article and amends it
+	link, content = "https://example.com/advisories/1", "<p>Affects 1.0 and 1.1</p><p>Upgrade soon.</p>"
+	_, _, err = c.fetchAllFeeds()
+	test.HandleError(t, err)
+
+	its, err = s.GetAllItems("asc")
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(its), "expected item to be matched on guid")
+	test.Equal(t, link, its[0].Link, "expected link to follow the feed")
+	test.Equal(t, true, its[0].Updated(), "expected read item to be flagged as updated")
+
+	revs, err = s.GetRevisions(its[0].ID)
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(revs), "expected one revision")
+	test.Equal(t, "<p>Affects 1.0</p><p>Upgrade soon.</p>", revs[0].Content, "expected revision to hold the old content")
+
+	lines := diffLines(articleLines(revs[0].Title, revs[0].Content), articleLines(its[0].Title, its[0].Content))
+	var changes []string
+	for _, l := range lines {
+		switch l.op {
+		case diffAdded:
+			changes = append(changes, "+"+l.text)
+		case diffRemoved:
+			changes = append(changes, "-"+l.text)
+		}
+	}
+	test.Equal(t, "-Affects 1.0|+Affects 1.0 and 1.1", strings.Join(changes, "|"), "expected only the changed line in the diff")
+
+	out, err := c.GetArticleDiff(its[0].ID, 80)
+	test.HandleError(t, err)
+	test.Equal(t, true, strings.Contains(out, "Affects 1.0 and 1.1"), "expected rendered diff to include the change")
+
+	_, err = c.GetGlamourisedArticle(its[0].ID)
+	test.HandleError(t, err)
+	it, err := s.GetItemByID(its[0].ID)
+	test.HandleError(t, err)
+	test.Equal(t, false, it.Updated(), "expected reading the article to clear the flag")
+}
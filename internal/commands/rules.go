@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+// ruleCmdTimeout bounds how long a rule's command can run for
+const ruleCmdTimeout = 30 * time.Second
+
+// feedFor returns the config for the feed an item came from
+func (c Commands) feedFor(url string) config.Feed {
+	for _, f := range c.config.GetFeeds() {
+		if f.URL == url {
+			return f
+		}
+	}
+
+	return config.Feed{URL: url}
+}
+
+// matchingRules returns the rules that match an item
+func (c Commands) matchingRules(rules []config.RuleMatcher, it store.Item) []config.RuleMatcher {
+	feed := c.feedFor(it.FeedURL)
+
+	var matched []config.RuleMatcher
+	for _, r := range rules {
+		if r.Match(feed, it.Title, it.Content, it.Author) {
+			matched = append(matched, r)
+		}
+	}
+
+	return matched
+}
+
+// applyRules runs the actions of every matching rule on each item, returning
+// the items with their new state. It is called with newly inserted items only,
+// so editing an item by hand afterwards isn't undone by the next refresh.
+func (c Commands) applyRules(its []store.Item) ([]store.Item, error) {
+	rules, err := c.config.CompileRules()
+	if err != nil {
+		return its, fmt.Errorf("applyRules: %w", err)
+	}
+
+	if len(rules) == 0 {
+		return its, nil
+	}
+
+	for i := range its {
+		for _, r := range c.matchingRules(rules, its[i]) {
+			err := c.applyRule(r, &its[i])
+			if err != nil {
+				return its, fmt.Errorf("applyRules: %w", err)
+			}
+		}
+	}
+
+	return its, nil
+}
+
+func (c Commands) applyRule(r config.RuleMatcher, it *store.Item) error {
+	if (r.Read && !it.Read()) || (r.Favourite && !it.Favourite) {
+		read := it.Read() || r.Read
+		err := c.store.SetItemState(it.ID, read, it.Favourite || r.Favourite)
+		if err != nil {
+			return err
+		}
+
+		if read && it.ReadAt.IsZero() {
+			it.ReadAt = time.Now()
+		}
+		it.Favourite = it.Favourite || r.Favourite
+	}
+
+	if r.Hide && !it.Hidden {
+		err := c.store.SetHidden(it.ID, true)
+		if err != nil {
+			return err
+		}
+		it.Hidden = true
+	}
+
+	if r.Label != "" {
+		err := c.store.AddLabel(it.ID, r.Label)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(it.Labels, r.Label) {
+			it.Labels = append(it.Labels, r.Label)
+		}
+	}
+
+	if r.Cmd != "" {
+		err := runRuleCmd(r, *it)
+		if err != nil {
+			log.Printf("[rules.go] applyRule: %s: %v", r.Rule, err)
+		}
+	}
+
+	return nil
+}
+
+// runRuleCmd runs a rule's command with the item in the environment rather
+// than in the arguments, so titles can't be interpreted as flags or shell
+func runRuleCmd(r config.RuleMatcher, it store.Item) error {
+	parts := strings.Fields(r.Cmd)
+	if len(parts) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ruleCmdTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
+	cmd.Env = append(os.Environ(),
+		"NOM_RULE="+r.Rule.String(),
+		"NOM_ID="+strconv.Itoa(it.ID),
+		"NOM_TITLE="+it.Title,
+		"NOM_LINK="+it.Link,
+		"NOM_AUTHOR="+it.Author,
+		"NOM_FEED_URL="+it.FeedURL,
+		"NOM_FEED_NAME="+it.FeedName,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", r.Cmd, err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func ruleActions(r config.Rule) string {
+	var actions []string
+	if r.Read {
+		actions = append(actions, "read")
+	}
+	if r.Favourite {
+		actions = append(actions, "favourite")
+	}
+	if r.Hide {
+		actions = append(actions, "hide")
+	}
+	if r.Label != "" {
+		actions = append(actions, "label:"+r.Label)
+	}
+	if r.Cmd != "" {
+		actions = append(actions, "cmd")
+	}
+
+	return strings.Join(actions, ",")
+}
+
+// TestRules replays the rules against the stored items, printing what
+// matches. With apply the actions are run as well, commands included.
+func (c Commands) TestRules(apply bool) error {
+	rules, err := c.config.CompileRules()
+	if err != nil {
+		return fmt.Errorf("commands TestRules: %w", err)
+	}
+
+	if len(rules) == 0 {
+		fmt.Println("no rules, add them under rules in the config file")
+		return nil
+	}
+
+	its, err := c.store.GetAllItems(c.config.Ordering)
+	if err != nil {
+		return fmt.Errorf("commands TestRules: %w", err)
+	}
+	c.addFeedInfo(its)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE\tACTIONS\tFEED\tTITLE")
+
+	matched := 0
+	for i := range its {
+		rs := c.matchingRules(rules, its[i])
+		if len(rs) > 0 {
+			matched++
+		}
+
+		for _, r := range rs {
+			feed := its[i].FeedName
+			if feed == "" {
+				feed = its[i].FeedURL
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Rule, ruleActions(r.Rule), feed, its[i].Title)
+
+			if !apply {
+				continue
+			}
+
+			err := c.applyRule(r, &its[i])
+			if err != nil {
+				return fmt.Errorf("commands TestRules: %w", err)
+			}
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return fmt.Errorf("commands TestRules: %w", err)
+	}
+
+	verb := "would be changed, run with --apply to apply"
+	if apply {
+		verb = "changed"
+	}
+	fmt.Printf("\n%d of %d items %s\n", matched, len(its), verb)
+
+	return nil
+}
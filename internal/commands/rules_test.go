@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+const rulesFeed = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>sec</title>
+<item><title>Sponsored: buy a VPN</title><link>https://example.com/1</link></item>
+<item><title>Security advisory for openssl</title><link>https://example.com/2</link></item>
+<item><title>Weekly notes</title><link>https://example.com/3</link></item>
+</channel></rss>`
+
+func TestRules(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rulesFeed)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Ordering: "asc",
+		Feeds:    []config.Feed{{URL: srv.URL, Tags: []string{"security"}}},
+		Rules: []config.Rule{
+			{Name: "mute sponsored", Title: "(?i)^sponsored", Hide: true, Read: true},
+			{Tag: "security", Title: "(?i)advisory", Favourite: true, Label: "advisory"},
+		},
+	}
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+
+	items, _, err := c.fetchAllFeeds()
+	test.HandleError(t, err)
+	test.Equal(t, true, items[0].Hidden, "expected fetched item to reflect rules")
+
+	its, err := c.GetAllFeeds()
+	test.HandleError(t, err)
+	test.Equal(t, 2, len(its), "expected sponsored item to be hidden")
+	test.Equal(t, true, its[0].Favourite, "expected advisory to be favourited")
+	test.Equal(t, "advisory", its[0].Labels[0], "expected advisory to be labelled")
+	test.Equal(t, false, its[1].Favourite, "expected other items to be left alone")
+
+	count, err := s.CountUnread()
+	test.HandleError(t, err)
+	test.Equal(t, 2, count, "hidden items shouldn't count as unread")
+
+	// rules only run on insert, so undoing one by hand sticks
+	test.HandleError(t, s.ToggleFavourite(its[0].ID))
+	_, _, err = c.fetchAllFeeds()
+	test.HandleError(t, err)
+
+	it, err := s.GetItemByID(its[0].ID)
+	test.HandleError(t, err)
+	test.Equal(t, false, it.Favourite, "expected rules not to run again")
+	test.Equal(t, "advisory", strings.Join(it.Labels, ","), "expected label not to be added twice")
+
+	_, err = config.Rule{Title: "("}.Compile()
+	test.Equal(t, true, err != nil, "expected invalid expression to fail")
+
+	_, err = config.Rule{Title: "x"}.Compile()
+	test.Equal(t, true, err != nil, "expected rule without actions to fail")
+}
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+)
+
+const (
+	// maxInterval caps the interval worked out for a feed, so a quiet feed
+	// is still fetched daily. A feed's own interval can be longer.
+	maxInterval = 24 * time.Hour
+	// publishSample is how many recent items a feed's rate is taken from
+	publishSample = 10
+	// scheduleTick is how often refreshEvery looks for feeds that are due
+	scheduleTick = time.Minute
+)
+
+// refreshInterval is RefreshInterval as a duration, 0 if it's not set
+func (c Commands) refreshInterval() time.Duration {
+	return time.Duration(c.config.RefreshInterval) * time.Minute
+}
+
+// hasFeedIntervals reports whether any feed sets its own interval
+func (c Commands) hasFeedIntervals() bool {
+	return slices.ContainsFunc(c.config.GetFeeds(), func(f config.Feed) bool {
+		return f.IntervalDuration() > 0
+	})
+}
+
+// feedInterval works out how long to wait before fetching a feed again. A
+// feed's own interval wins, otherwise it's the longer of the feed's hint and
+// its publishing rate, capped at maxInterval and never shorter than
+// RefreshInterval.
+func (c Commands) feedInterval(f config.Feed, hint time.Duration, now time.Time, published []time.Time) time.Duration {
+	if d := f.IntervalDuration(); d > 0 {
+		return d
+	}
+
+	d := min(max(hint, publishInterval(now, published)), maxInterval)
+	return max(c.refreshInterval(), d)
+}
+
+// publishInterval returns half the usual gap between a feed's items, so a
+// feed is fetched about twice for each new item. A feed that's been quiet for
+// longer than usual is fetched less often. It's 0 with too few items to tell.
+func publishInterval(now time.Time, published []time.Time) time.Duration {
+	if len(published) < 2 {
+		return 0
+	}
+
+	gaps := make([]time.Duration, 0, len(published)-1)
+	for i := 1; i < len(published); i++ {
+		gaps = append(gaps, published[i-1].Sub(published[i]).Abs())
+	}
+	slices.Sort(gaps)
+
+	return max(gaps[len(gaps)/2], now.Sub(published[0])) / 2
+}
+
+// scheduleFeed records when a feed is next due. Errors are only logged, as
+// the worst case is that the feed is fetched again sooner.
+func (c Commands) scheduleFeed(feedURL string, hint time.Duration, fetchedAt time.Time) {
+	published, err := c.store.GetPublishTimes(feedURL, publishSample)
+	if err != nil {
+		log.Printf("[schedule.go] scheduleFeed: %v", err)
+	}
+
+	d := c.feedInterval(c.feedFor(feedURL), hint, fetchedAt, published)
+	err = c.store.SetNextFetch(feedURL, fetchedAt.Add(d))
+	if err != nil {
+		log.Printf("[schedule.go] scheduleFeed: %v", err)
+	}
+}
+
+// dueFeeds returns the feeds due to be fetched at now. Feeds that have never
+// been fetched are always due.
+func (c Commands) dueFeeds(now time.Time) ([]config.Feed, error) {
+	metas, err := c.store.GetAllFeedMeta()
+	if err != nil {
+		return nil, fmt.Errorf("dueFeeds: %w", err)
+	}
+
+	synced := c.syncedBackends()
+
+	var due []config.Feed
+	for _, f := range c.config.GetFeeds() {
+		if synced[f.Backend] {
+			continue
+		}
+
+		meta := metas[f.URL]
+		next := meta.NextFetchAt
+		// a changed interval applies straight away
+		if d := f.IntervalDuration(); d > 0 && !meta.LastFetchedAt.IsZero() {
+			next = meta.LastFetchedAt.Add(d)
+		}
+
+		if next.IsZero() || !now.Before(next) {
+			due = append(due, f)
+		}
+	}
+
+	return due, nil
+}
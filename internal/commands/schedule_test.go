@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+// scheduleFeed returns a feed with n items published every gap, the newest
+// now
+func scheduleFeed(n int, gap time.Duration, extra string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0"?><rss version="2.0"><channel><title>t</title>%s`, extra)
+	for i := range n {
+		pub := time.Now().Add(-time.Duration(i) * gap).Format(time.RFC1123Z)
+		fmt.Fprintf(&b, `<item><title>%d</title><link>https://example.com/%d</link><pubDate>%s</pubDate></item>`, i, i, pub)
+	}
+	b.WriteString(`</channel></rss>`)
+	return b.String()
+}
+
+func TestSchedule(t *testing.T) {
+	feeds := map[string]string{
+		"/news":  scheduleFeed(5, 2*time.Minute, ""),
+		"/daily": scheduleFeed(5, 24*time.Hour, ""),
+		"/ttl":   scheduleFeed(5, 2*time.Minute, "<ttl>180</ttl>"),
+		"/fixed": scheduleFeed(5, 2*time.Minute, ""),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, feeds[r.URL.Path])
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		RefreshInterval: 5,
+		Feeds: []config.Feed{
+			{URL: srv.URL + "/news"},
+			{URL: srv.URL + "/daily"},
+			{URL: srv.URL + "/ttl"},
+			{URL: srv.URL + "/fixed", Interval: "2d"},
+		},
+	}
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+
+	due, err := c.dueFeeds(time.Now())
+	test.HandleError(t, err)
+	test.Equal(t, 4, len(due), "unfetched feeds should be due")
+
+	_, _, err = c.fetchAllFeeds()
+	test.HandleError(t, err)
+
+	metas, err := s.GetAllFeedMeta()
+	test.HandleError(t, err)
+
+	interval := func(path string) time.Duration {
+		m := metas[srv.URL+path]
+		return m.NextFetchAt.Sub(m.LastFetchedAt).Round(time.Minute)
+	}
+	test.Equal(t, 5*time.Minute, interval("/news"), "busy feed should stay on refreshinterval")
+	test.Equal(t, 12*time.Hour, interval("/daily"), "daily feed should be fetched twice a day")
+	test.Equal(t, 3*time.Hour, interval("/ttl"), "ttl should be honoured")
+	test.Equal(t, 48*time.Hour, interval("/fixed"), "feed interval should win")
+	test.Equal(t, 3*time.Hour, metas[srv.URL+"/ttl"].UpdateHint, "hint should be stored")
+
+	due, err = c.dueFeeds(time.Now().Add(10 * time.Minute))
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(due), "only the busy feed should be due")
+	test.Equal(t, srv.URL+"/news", due[0].URL, "wrong feed due")
+}
+
+func TestRefreshFeedIntervalsOnly(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, scheduleFeed(2, time.Hour, ""))
+	}))
+	defer srv.Close()
+
+	// no refreshinterval, so only the feed with its own interval is refreshed
+	cfg := &config.Config{
+		Feeds: []config.Feed{
+			{URL: srv.URL + "/auto"},
+			{URL: srv.URL + "/fixed", Interval: "1h"},
+		},
+	}
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+	test.Equal(t, true, c.hasFeedIntervals(), "expected a feed interval to be found")
+
+	var fetched []store.Item
+	ran := c.refreshDue(time.Now(), false, func(items []store.Item, _ []ErrorItem, err error) {
+		test.HandleError(t, err)
+		fetched = items
+	})
+	test.Equal(t, true, ran, "expected the feed with an interval to be refreshed")
+	test.Equal(t, 2, len(fetched), "expected only the fixed feed's items")
+	test.Equal(t, srv.URL+"/fixed", fetched[0].FeedURL, "wrong feed refreshed")
+
+	c = New(&config.Config{Feeds: cfg.Feeds[:1]}, s)
+	test.Equal(t, false, c.hasFeedIntervals(), "expected no feed intervals")
+}
+
+func TestPublishTimesMixedOffsets(t *testing.T) {
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	// east is the oldest, though its local time is the latest
+	east := time.FixedZone("", 5*60*60)
+	for i, at := range []time.Time{
+		time.Date(2026, 1, 2, 12, 0, 0, 0, east),
+		time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC),
+	} {
+		it := store.Item{FeedURL: "a", Link: fmt.Sprintf("a/%d", i), PublishedAt: at}
+		_, err = s.UpsertItem(&it)
+		test.HandleError(t, err)
+	}
+
+	times, err := s.GetPublishTimes("a", 2)
+	test.HandleError(t, err)
+	test.Equal(t, 2, len(times), "expected limit to be respected")
+	test.Equal(t, 9, times[0].UTC().Hour(), "wrong newest time")
+	test.Equal(t, 8, times[1].UTC().Hour(), "wrong second time")
+}
@@ -0,0 +1,44 @@
+//go:build sqlite_fts5
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+func TestSearch(t *testing.T) {
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	items := []store.Item{
+		{FeedURL: "a", Link: "a/1", Title: "Well-known bugs"},
+		{FeedURL: "a", Link: "a/2", Title: "Say \"hello\" to bugs"},
+		{FeedURL: "a", Link: "a/3", Title: "Muted bugs"},
+	}
+	for i := range items {
+		_, err := s.UpsertItem(&items[i])
+		test.HandleError(t, err)
+	}
+	test.HandleError(t, s.SetHidden(items[2].ID, true))
+
+	c := New(&config.Config{Feeds: []config.Feed{{URL: "a", Name: "Feed A"}}}, s)
+
+	found, err := c.search("bugs")
+	test.HandleError(t, err)
+	test.Equal(t, 2, len(found), "expected hidden items to be left out")
+	test.Equal(t, "Feed A", found[0].FeedName, "expected feed names to be filled in")
+
+	found, err = c.search("well-known")
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(found), "expected a hyphenated word to be matched as a phrase")
+	test.Equal(t, items[0].ID, found[0].ID, "expected a hyphenated word to be matched as a phrase")
+
+	found, err = c.search(`"hello`)
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(found), "expected a stray quote to be matched literally")
+	test.Equal(t, items[1].ID, found[0].ID, "expected a stray quote to be matched literally")
+}
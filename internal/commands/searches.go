@@ -0,0 +1,177 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+// GetItems is GetAllFeeds narrowed down to the saved search with the given
+// name, or every item if it's empty
+func (c Commands) GetItems(search string) ([]store.Item, error) {
+	its, err := c.GetAllFeeds()
+	if err != nil {
+		return nil, fmt.Errorf("commands GetItems: %w", err)
+	}
+
+	if search == "" {
+		return its, nil
+	}
+
+	s, err := c.config.GetSearch(search)
+	if err != nil {
+		return nil, fmt.Errorf("commands GetItems: %w", err)
+	}
+
+	return c.searchFilterer(s).FilterItems(its), nil
+}
+
+func (c Commands) searchFilterer(s config.Search) *Filterer {
+	f := NewFilterer(s.Query, *c.config)
+	f.Store = c.store
+	return &f
+}
+
+type searchCount struct {
+	Name   string
+	Unread int
+}
+
+// searchCounts returns the unread count of every saved search, counted over
+// the same items GetItems lists so muted items and copies are left out
+func (c Commands) searchCounts() ([]searchCount, error) {
+	its, err := c.GetAllFeeds()
+	if err != nil {
+		return nil, fmt.Errorf("commands searchCounts: %w", err)
+	}
+
+	var counts []searchCount
+	for _, s := range c.config.Searches {
+		count := searchCount{Name: s.Name}
+		for _, it := range c.searchFilterer(s).FilterItems(its) {
+			if !it.Read() {
+				count.Unread++
+			}
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+var searchKeys = struct {
+	Up     key.Binding
+	Down   key.Binding
+	Select key.Binding
+	Close  key.Binding
+}{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "down"),
+	),
+	Select: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "select"),
+	),
+	Close: key.NewBinding(
+		key.WithKeys("esc", "q", "S"),
+		key.WithHelp("q/esc", "close"),
+	),
+}
+
+// openSearches shows the saved search picker. The first entry is every
+// item, followed by each saved search with its unread count.
+func (m *model) openSearches() tea.Cmd {
+	if len(m.cfg.Searches) == 0 {
+		return m.list.NewStatusMessage("No saved searches, add some under searches in the config file.")
+	}
+
+	counts, err := m.commands.searchCounts()
+	if err != nil {
+		return m.list.NewStatusMessage(fmt.Sprintf("Error: %s", err))
+	}
+
+	m.searches = counts
+	m.searchIndex = 0
+	for i, s := range counts {
+		if s.Name == m.search {
+			m.searchIndex = i + 1
+		}
+	}
+	m.showSearches = true
+
+	return nil
+}
+
+func (m model) listTitle() string {
+	if m.search == "" {
+		return defaultTitle
+	}
+	return defaultTitle + ": " + m.search
+}
+
+func (m *model) setListTitle(title string) {
+	m.list.Title = title
+	m.list.Styles.Title = m.list.Styles.Title.Width(lipgloss.Width(title) + 2)
+}
+
+func updateSearches(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, ViewportKeyMap.Quit):
+		return m, tea.Quit
+	case key.Matches(keyMsg, searchKeys.Close):
+		m.showSearches = false
+	case key.Matches(keyMsg, searchKeys.Up):
+		m.searchIndex = max(m.searchIndex-1, 0)
+	case key.Matches(keyMsg, searchKeys.Down):
+		m.searchIndex = min(m.searchIndex+1, len(m.searches))
+	case key.Matches(keyMsg, searchKeys.Select):
+		m.showSearches = false
+		m.search = ""
+		if m.searchIndex > 0 {
+			m.search = m.searches[m.searchIndex-1].Name
+		}
+
+		m.list.ResetFilter()
+		m.list.ResetSelected()
+		m.setListTitle(m.listTitle())
+		return m, m.UpdateList()
+	}
+
+	return m, nil
+}
+
+func searchesView(m model) string {
+	lines := []string{"All items"}
+	for _, s := range m.searches {
+		lines = append(lines, fmt.Sprintf("%s (%d unread)", s.Name, s.Unread))
+	}
+
+	var b strings.Builder
+	b.WriteString("\nSaved searches\n\n")
+	for i, l := range lines {
+		if i == m.searchIndex {
+			b.WriteString(selectedItemStyle.Foreground(lipgloss.Color(m.cfg.Theme.SelectedItemColor)).Render("> "+l) + "\n")
+		} else {
+			b.WriteString(itemStyle.Render(l) + "\n")
+		}
+	}
+
+	help := m.help.ShortHelpView([]key.Binding{searchKeys.Up, searchKeys.Down, searchKeys.Select, searchKeys.Close})
+	return b.String() + "\n" + helpStyle.Render(help)
+}
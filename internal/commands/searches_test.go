@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+func TestSearches(t *testing.T) {
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	for _, it := range []store.Item{
+		{FeedURL: "https://go.dev/feed", Link: "go/1", Title: "Go 1.24 release notes"},
+		{FeedURL: "https://go.dev/feed", Link: "go/2", Title: "Go 1.23 release notes"},
+		{FeedURL: "https://go.dev/feed", Link: "go/3", Title: "Generics deep dive"},
+		{FeedURL: "https://rust.dev/feed", Link: "rust/1", Title: "Rust release"},
+	} {
+		_, err := s.UpsertItem(&it)
+		test.HandleError(t, err)
+	}
+	test.HandleError(t, s.ToggleRead(2))
+
+	cfg := &config.Config{
+		Ordering: "asc",
+		Feeds: []config.Feed{
+			{URL: "https://go.dev/feed", Tags: []string{"go"}},
+			{URL: "https://rust.dev/feed", Tags: []string{"rust"}},
+		},
+		Searches: []config.Search{
+			{Name: "Go releases", Query: "tag:go title:release"},
+			{Name: "Not go", Query: "-tag:go"},
+		},
+	}
+	cmds := New(cfg, s)
+
+	its, err := cmds.GetItems("go releases")
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(its), "expected unread matching items")
+	test.Equal(t, "Go 1.24 release notes", its[0].Title, "wrong item")
+
+	its, err = cmds.GetItems("")
+	test.HandleError(t, err)
+	test.Equal(t, 3, len(its), "expected every unread item without a search")
+
+	_, err = cmds.GetItems("missing")
+	test.Equal(t, true, errors.Is(err, config.ErrSearchNotFound), "expected unknown search to fail")
+
+	// muted items aren't listed, so aren't counted either
+	muted := store.Item{FeedURL: "https://rust.dev/feed", Link: "rust/2", Title: "Sponsored"}
+	_, err = s.UpsertItem(&muted)
+	test.HandleError(t, err)
+	test.HandleError(t, s.SetHidden(muted.ID, true))
+
+	counts, err := cmds.searchCounts()
+	test.HandleError(t, err)
+	test.Equal(t, 2, len(counts), "expected a count per search")
+	test.Equal(t, searchCount{Name: "Go releases", Unread: 1}, counts[0], "wrong count")
+	test.Equal(t, searchCount{Name: "Not go", Unread: 1}, counts[1], "wrong count")
+}
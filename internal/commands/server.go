@@ -0,0 +1,432 @@
+package commands
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/constants"
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+// maxPageSize caps the limit parameter of the items endpoint
+const maxPageSize = 1000
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// FeedRecord is a feed with its item counts and last fetch status
+type FeedRecord struct {
+	URL           string     `json:"url"`
+	Name          string     `json:"name,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`
+	Items         int        `json:"items"`
+	Unread        int        `json:"unread"`
+	LastFetchedAt *time.Time `json:"lastFetchedAt,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	Failures      int        `json:"failures"`
+}
+
+type ServeOptions struct {
+	Addr string
+	// Token is required as a bearer token on every API request when set
+	Token string
+	// Fever serves the Fever API under /fever/ as well
+	Fever bool
+}
+
+// Serve runs the HTTP API until it fails. Feeds are refreshed in the
+// background as they fall due, as they are in the TUI.
+func (c Commands) Serve(opts ServeOptions) error {
+	if opts.Fever && (c.config.Fever == nil || c.config.Fever.Username == "" || c.config.Fever.Password == "") {
+		return fmt.Errorf("commands Serve: fever needs a username and password in the config file")
+	}
+
+	go c.refreshEvery(nil, func(_ []store.Item, errorItems []ErrorItem, err error) {
+		if err != nil {
+			log.Printf("[server.go] refresh failed: %v", err)
+			return
+		}
+
+		for _, e := range errorItems {
+			log.Printf("[server.go] error fetching %s: %v", e.FeedURL, e.Err)
+		}
+	})
+
+	fmt.Printf("listening on http://%s\n", opts.Addr)
+	if opts.Fever {
+		fmt.Printf("fever API on http://%s/fever/\n", opts.Addr)
+	}
+
+	srv := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           c.serverHandler(opts),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	err := srv.ListenAndServe()
+	if err != nil {
+		return fmt.Errorf("commands Serve: %w", err)
+	}
+
+	return nil
+}
+
+func (c Commands) serverHandler(opts ServeOptions) http.Handler {
+	mux := http.NewServeMux()
+
+	// fever clients authenticate with their own api_key
+	if opts.Fever {
+		mux.Handle("/fever", c.feverHandler())
+		mux.Handle("/fever/", c.feverHandler())
+	}
+
+	var api http.Handler = c.apiHandler()
+	if opts.Token != "" {
+		api = requireToken(opts.Token, api)
+	}
+	mux.Handle("/api/", api)
+
+	return mux
+}
+
+func (c Commands) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/items", c.handleListItems)
+	mux.HandleFunc("GET /api/items/{id}", c.handleGetItem)
+	mux.HandleFunc("PATCH /api/items/{id}", c.handleUpdateItem)
+	mux.HandleFunc("POST /api/items/{id}/toggle-read", c.handleToggle(c.store.ToggleRead))
+	mux.HandleFunc("POST /api/items/{id}/toggle-favourite", c.handleToggle(c.store.ToggleFavourite))
+	mux.HandleFunc("POST /api/items/mark-all-read", c.handleMarkAllRead)
+	mux.HandleFunc("GET /api/unread", c.handleUnread)
+	mux.HandleFunc("GET /api/feeds", c.handleListFeeds)
+	mux.HandleFunc("POST /api/refresh", c.handleRefresh)
+
+	return mux
+}
+
+func requireToken(token string, next http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeAPIError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("[server.go] writeAPIJSON: %v", err)
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, apiError{Error: err.Error()})
+}
+
+func parseBoolParam(r *http.Request, name string) (*bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %q", name, v)
+	}
+
+	return &b, nil
+}
+
+func parseIntParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, v)
+	}
+
+	return i, nil
+}
+
+// handleListItems lists items, filtered by the read, favourite, feed (url or
+// name), tag and q (full text search) parameters, and paged with limit and
+// offset. order is asc or desc, defaulting to the configured ordering.
+func (c Commands) handleListItems(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	read, err := parseBoolParam(r, "read")
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	favourite, err := parseBoolParam(r, "favourite")
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	hidden, err := parseBoolParam(r, "hidden")
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	if hidden == nil {
+		hidden = new(bool)
+	}
+
+	limit, err := parseIntParam(r, "limit", maxPageSize)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit = min(limit, maxPageSize)
+
+	offset, err := parseIntParam(r, "offset", 0)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ordering := c.config.Ordering
+	if o := q.Get("order"); o != "" {
+		if o != constants.AscendingOrdering && o != constants.DescendingOrdering {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid order: %q", o))
+			return
+		}
+		ordering = o
+	}
+
+	its, err := c.store.GetAllItems(ordering)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var matches map[int]bool
+	if search := q.Get("q"); search != "" {
+		found, err := c.store.Search(store.QuoteSearchTerm(search))
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		matches = map[int]bool{}
+		for _, f := range found {
+			matches[f.ID] = true
+		}
+	}
+
+	c.addFeedInfo(its)
+
+	feed := q.Get("feed")
+	tag := q.Get("tag")
+
+	filtered := []store.Item{}
+	for _, it := range its {
+		if read != nil && it.Read() != *read {
+			continue
+		}
+		if favourite != nil && it.Favourite != *favourite {
+			continue
+		}
+		if it.Hidden != *hidden {
+			continue
+		}
+		if feed != "" && it.FeedURL != feed && !strings.EqualFold(it.FeedName, feed) {
+			continue
+		}
+		if tag != "" && !slices.ContainsFunc(it.Tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			continue
+		}
+		if matches != nil && !matches[it.ID] {
+			continue
+		}
+
+		filtered = append(filtered, it)
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(filtered)))
+
+	start := min(offset, len(filtered))
+	end := min(start+limit, len(filtered))
+	writeAPIJSON(w, http.StatusOK, toItemRecords(filtered[start:end]))
+}
+
+// itemFromPath loads the item named by the {id} path segment, writing an
+// error response and returning false if it can't
+func (c Commands) itemFromPath(w http.ResponseWriter, r *http.Request) (store.Item, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid id: %q", r.PathValue("id")))
+		return store.Item{}, false
+	}
+
+	it, err := c.store.GetItemByID(id)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("item %d not found", id))
+		return store.Item{}, false
+	}
+
+	its := []store.Item{it}
+	c.addFeedInfo(its)
+
+	return its[0], true
+}
+
+type itemWithContent struct {
+	ItemRecord
+	Content string `json:"content"`
+}
+
+func (c Commands) handleGetItem(w http.ResponseWriter, r *http.Request) {
+	it, ok := c.itemFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, itemWithContent{toItemRecord(it), it.Content})
+}
+
+// handleUpdateItem sets read and/or favourite from the JSON body. The
+// toggles are used so that changes are sent to syncing backends.
+func (c Commands) handleUpdateItem(w http.ResponseWriter, r *http.Request) {
+	it, ok := c.itemFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Read      *bool `json:"read"`
+		Favourite *bool `json:"favourite"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+		return
+	}
+
+	if body.Read != nil {
+		err = c.store.SetRead(it.ID, *body.Read)
+	}
+	if err == nil && body.Favourite != nil && *body.Favourite != it.Favourite {
+		err = c.store.ToggleFavourite(it.ID)
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.handleGetItem(w, r)
+}
+
+func (c Commands) handleToggle(toggle func(ID int) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		it, ok := c.itemFromPath(w, r)
+		if !ok {
+			return
+		}
+
+		err := toggle(it.ID)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		c.handleGetItem(w, r)
+	}
+}
+
+func (c Commands) handleMarkAllRead(w http.ResponseWriter, r *http.Request) {
+	err := c.store.MarkAllRead()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c Commands) handleUnread(w http.ResponseWriter, r *http.Request) {
+	count, err := c.store.CountUnread()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, map[string]int{"unread": count})
+}
+
+func (c Commands) handleListFeeds(w http.ResponseWriter, r *http.Request) {
+	stats, err := c.store.GetFeedStats()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	metas, err := c.store.GetAllFeedMeta()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	feeds := []FeedRecord{}
+	for _, f := range c.config.GetFeeds() {
+		meta := metas[f.URL]
+		feeds = append(feeds, FeedRecord{
+			URL:           f.URL,
+			Name:          f.Name,
+			Tags:          f.Tags,
+			Items:         stats[f.URL].Items,
+			Unread:        stats[f.URL].Unread,
+			LastFetchedAt: optionalTime(meta.LastFetchedAt),
+			LastError:     meta.LastError,
+			Failures:      meta.Failures,
+		})
+	}
+
+	writeAPIJSON(w, http.StatusOK, feeds)
+}
+
+// handleRefresh fetches all feeds before responding, waiting for any refresh
+// already in progress
+func (c Commands) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	its, errorItems, err := c.fetchAllFeeds()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	errs := []ErrorRecord{}
+	for _, e := range errorItems {
+		errs = append(errs, ErrorRecord{FeedURL: e.FeedURL, Error: e.Err.Error()})
+	}
+
+	writeAPIJSON(w, http.StatusOK, struct {
+		Items  int           `json:"items"`
+		Errors []ErrorRecord `json:"errors"`
+	}{len(its), errs})
+}
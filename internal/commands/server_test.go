@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+func newTestServer(t *testing.T, token string) (*httptest.Server, store.Store) {
+	t.Helper()
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	for i, feed := range []string{"https://a.com/feed", "https://a.com/feed", "https://b.com/feed"} {
+		it := store.Item{FeedURL: feed, Link: fmt.Sprintf("%s/%d", feed, i), Title: fmt.Sprintf("item %d", i), Content: "about golang"}
+		_, err := s.UpsertItem(&it)
+		test.HandleError(t, err)
+	}
+
+	cfg := &config.Config{
+		Ordering: "asc",
+		Feeds: []config.Feed{
+			{URL: "https://a.com/feed", Name: "A", Tags: []string{"news"}},
+			{URL: "https://b.com/feed"},
+		},
+	}
+
+	srv := httptest.NewServer(New(cfg, s).serverHandler(ServeOptions{Token: token}))
+	t.Cleanup(srv.Close)
+
+	return srv, s
+}
+
+func apiRequest(t *testing.T, method string, url string, body string, token string) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	test.HandleError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	test.HandleError(t, err)
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	test.HandleError(t, err)
+
+	return resp, b
+}
+
+func TestServerItems(t *testing.T) {
+	srv, _ := newTestServer(t, "")
+
+	resp, body := apiRequest(t, http.MethodGet, srv.URL+"/api/items?tag=news", "", "")
+	test.Equal(t, http.StatusOK, resp.StatusCode, "wrong status")
+
+	var items []ItemRecord
+	test.HandleError(t, json.Unmarshal(body, &items))
+	test.Equal(t, 2, len(items), "expected items tagged news")
+	test.Equal(t, "A", items[0].FeedName, "expected feed name from config")
+
+	resp, body = apiRequest(t, http.MethodPatch, fmt.Sprintf("%s/api/items/%d", srv.URL, items[0].ID), `{"read": true, "favourite": true}`, "")
+	test.Equal(t, http.StatusOK, resp.StatusCode, "wrong status")
+
+	var updated ItemRecord
+	test.HandleError(t, json.Unmarshal(body, &updated))
+	test.Equal(t, true, updated.Read, "expected item to be read")
+	test.Equal(t, true, updated.Favourite, "expected item to be favourite")
+
+	_, body = apiRequest(t, http.MethodGet, srv.URL+"/api/items?read=false", "", "")
+	test.HandleError(t, json.Unmarshal(body, &items))
+	test.Equal(t, 2, len(items), "expected two unread items")
+
+	resp, body = apiRequest(t, http.MethodPost, fmt.Sprintf("%s/api/items/%d/toggle-read", srv.URL, updated.ID), "", "")
+	test.Equal(t, http.StatusOK, resp.StatusCode, "wrong status")
+	test.HandleError(t, json.Unmarshal(body, &updated))
+	test.Equal(t, false, updated.Read, "expected item to be unread again")
+
+	_, body = apiRequest(t, http.MethodGet, srv.URL+"/api/items?limit=1&offset=2", "", "")
+	test.HandleError(t, json.Unmarshal(body, &items))
+	test.Equal(t, 1, len(items), "expected a page of one")
+	test.Equal(t, "item 2", items[0].Title, "wrong page")
+
+	resp, _ = apiRequest(t, http.MethodGet, srv.URL+"/api/items/999", "", "")
+	test.Equal(t, http.StatusNotFound, resp.StatusCode, "expected missing item")
+
+	resp, _ = apiRequest(t, http.MethodPost, srv.URL+"/api/items/mark-all-read", "", "")
+	test.Equal(t, http.StatusNoContent, resp.StatusCode, "wrong status")
+
+	_, body = apiRequest(t, http.MethodGet, srv.URL+"/api/unread", "", "")
+	test.Equal(t, `{"unread":0}`, strings.TrimSpace(string(body)), "expected everything read")
+}
+
+func TestServerFeeds(t *testing.T) {
+	srv, _ := newTestServer(t, "")
+
+	_, body := apiRequest(t, http.MethodGet, srv.URL+"/api/feeds", "", "")
+
+	var feeds []FeedRecord
+	test.HandleError(t, json.Unmarshal(body, &feeds))
+	test.Equal(t, 2, len(feeds), "wrong number of feeds")
+	test.Equal(t, 2, feeds[0].Unread, "wrong unread count")
+	test.Equal(t, 1, feeds[1].Items, "wrong item count")
+}
+
+func TestServerToken(t *testing.T) {
+	srv, _ := newTestServer(t, "secret")
+
+	resp, _ := apiRequest(t, http.MethodGet, srv.URL+"/api/unread", "", "")
+	test.Equal(t, http.StatusUnauthorized, resp.StatusCode, "expected missing token to be rejected")
+
+	resp, _ = apiRequest(t, http.MethodGet, srv.URL+"/api/unread", "", "wrong")
+	test.Equal(t, http.StatusUnauthorized, resp.StatusCode, "expected wrong token to be rejected")
+
+	resp, _ = apiRequest(t, http.MethodGet, srv.URL+"/api/unread", "", "secret")
+	test.Equal(t, http.StatusOK, resp.StatusCode, "expected token to be accepted")
+}
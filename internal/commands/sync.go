@@ -0,0 +1,223 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/guyfedwards/nom/v2/internal/backends"
+	"github.com/guyfedwards/nom/v2/internal/store"
+)
+
+// syncedBackends returns the IDs of the backends that are synced, whose feeds
+// aren't fetched directly
+func (c Commands) syncedBackends() map[string]bool {
+	synced := map[string]bool{}
+	for _, s := range c.syncers() {
+		synced[s.ID()] = true
+	}
+
+	return synced
+}
+
+// syncers returns a Syncer for every backend that has sync enabled
+func (c Commands) syncers() []backends.Syncer {
+	var ss []backends.Syncer
+
+	if c.config.Backends == nil || c.config.IsPreviewMode() {
+		return ss
+	}
+
+	for _, b := range c.config.Backends.Miniflux {
+		if b.Sync {
+			ss = append(ss, backends.NewMiniflux(b))
+		}
+	}
+
+	for _, b := range c.config.Backends.FreshRSS {
+		if b.Sync {
+			ss = append(ss, backends.NewGReader(b.GReader()))
+		}
+	}
+
+	for _, b := range c.config.Backends.GReader {
+		if b.Sync {
+			ss = append(ss, backends.NewGReader(b))
+		}
+	}
+
+	return ss
+}
+
+// syncBackends sends local read/favourite changes to each syncing backend,
+// then pulls its entries and state. Local changes that could not be sent
+// win over the backend state until they are. Items stored for the first time
+// are also returned in newItems.
+func (c Commands) syncBackends() (items []store.Item, newItems []store.Item, errorItems []ErrorItem) {
+	for _, s := range c.syncers() {
+		its, newIts, err := c.syncBackend(s)
+		if err != nil {
+			errorItems = append(errorItems, ErrorItem{FeedURL: s.ID(), Err: err})
+		}
+		items = append(items, its...)
+		newItems = append(newItems, newIts...)
+	}
+
+	return items, newItems, errorItems
+}
+
+func (c Commands) syncBackend(s backends.Syncer) ([]store.Item, []store.Item, error) {
+	err := c.pushChanges(s)
+	if err != nil {
+		log.Printf("[sync.go] syncBackend: %v", err)
+	}
+
+	entries, err := s.Entries()
+	if err != nil {
+		return nil, nil, fmt.Errorf("syncBackend: %w", err)
+	}
+
+	remotes, err := c.store.GetRemoteItems(s.ID())
+	if err != nil {
+		return nil, nil, fmt.Errorf("syncBackend: %w", err)
+	}
+
+	known := map[string]store.RemoteItem{}
+	for _, r := range remotes {
+		known[r.RemoteID] = r
+	}
+
+	err = c.store.BeginBatch()
+	if err != nil {
+		return nil, nil, fmt.Errorf("syncBackend: %w", err)
+	}
+	defer c.store.EndBatch()
+
+	var items, newItems []store.Item
+	returned := map[string]bool{}
+	for _, e := range entries {
+		returned[e.RemoteID] = true
+
+		i := store.Item{
+			Author:      e.Author,
+			Content:     e.Content,
+			FeedURL:     e.FeedURL,
+			Link:        e.Link,
+			GUID:        e.GUID,
+			PublishedAt: e.PublishedAt,
+			Title:       e.Title,
+		}
+
+		// items dropped by retention stay gone while the backend still lists them
+		pruned, err := c.store.WasPruned(&i)
+		if err != nil {
+			log.Printf("[sync.go] syncBackend: failed to check pruned items: %v", err)
+		}
+		if pruned {
+			continue
+		}
+
+		inserted, err := c.store.UpsertItem(&i)
+		if err != nil {
+			log.Printf("[sync.go] syncBackend: failed to upsert item: %v", err)
+			continue
+		}
+
+		if r, ok := known[e.RemoteID]; !ok || !r.Dirty() {
+			err = c.store.SetRemoteID(i.ID, s.ID(), e.RemoteID)
+			if err != nil {
+				return items, newItems, fmt.Errorf("syncBackend: %w", err)
+			}
+
+			err = c.store.SetItemState(i.ID, e.Read, e.Starred)
+			if err != nil {
+				return items, newItems, fmt.Errorf("syncBackend: %w", err)
+			}
+
+			// keep the state on the item too, so rules run over new items
+			// don't undo it
+			if e.Read && i.ReadAt.IsZero() {
+				i.ReadAt = time.Now()
+			}
+			i.Favourite = e.Starred
+		}
+
+		items = append(items, i)
+		if inserted {
+			newItems = append(newItems, i)
+		}
+	}
+
+	// anything no longer unread or starred was read and unstarred elsewhere
+	for _, r := range remotes {
+		if returned[r.RemoteID] || r.Dirty() || (r.Read && !r.Favourite) {
+			continue
+		}
+
+		err := c.store.SetItemState(r.ItemID, true, false)
+		if err != nil {
+			return items, newItems, fmt.Errorf("syncBackend: %w", err)
+		}
+	}
+
+	return items, newItems, c.store.EndBatch()
+}
+
+// pushChanges sends dirty items to the backend and clears them once sent
+func (c Commands) pushChanges(s backends.Syncer) error {
+	remotes, err := c.store.GetRemoteItems(s.ID())
+	if err != nil {
+		return fmt.Errorf("pushChanges: %w", err)
+	}
+
+	var read, unread, starred, unstarred []string
+	var sent []int
+	for _, r := range remotes {
+		if r.ReadDirty && r.Read {
+			read = append(read, r.RemoteID)
+		} else if r.ReadDirty {
+			unread = append(unread, r.RemoteID)
+		}
+
+		if r.FavouriteDirty && r.Favourite {
+			starred = append(starred, r.RemoteID)
+		} else if r.FavouriteDirty {
+			unstarred = append(unstarred, r.RemoteID)
+		}
+
+		if r.Dirty() {
+			sent = append(sent, r.ItemID)
+		}
+	}
+
+	if len(sent) == 0 {
+		return nil
+	}
+
+	for _, update := range []struct {
+		ids   []string
+		value bool
+		fn    func([]string, bool) error
+	}{
+		{read, true, s.SetRead},
+		{unread, false, s.SetRead},
+		{starred, true, s.SetStarred},
+		{unstarred, false, s.SetStarred},
+	} {
+		if len(update.ids) == 0 {
+			continue
+		}
+
+		err := update.fn(update.ids, update.value)
+		if err != nil {
+			return fmt.Errorf("pushChanges: %w", err)
+		}
+	}
+
+	err = c.store.ClearDirty(sent)
+	if err != nil {
+		return fmt.Errorf("pushChanges: %w", err)
+	}
+
+	return nil
+}
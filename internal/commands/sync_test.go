@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	miniflux "miniflux.app/v2/client"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+	"github.com/guyfedwards/nom/v2/internal/store"
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+const minifluxFeedURL = "https://example.com/feed.xml"
+
+// fakeMiniflux is a stand-in for the parts of the Miniflux API used by sync
+type fakeMiniflux struct {
+	mu      sync.Mutex
+	entries map[int64]*miniflux.Entry
+}
+
+func newFakeMiniflux() *fakeMiniflux {
+	f := &fakeMiniflux{entries: map[int64]*miniflux.Entry{}}
+	for id := int64(1); id <= 3; id++ {
+		f.entries[id] = &miniflux.Entry{
+			ID:     id,
+			URL:    "https://example.com/" + strconv.FormatInt(id, 10),
+			Title:  "entry " + strconv.FormatInt(id, 10),
+			Status: miniflux.EntryStatusUnread,
+			Date:   time.Now(),
+			Feed:   &miniflux.Feed{FeedURL: minifluxFeedURL},
+		}
+	}
+	return f
+}
+
+func (f *fakeMiniflux) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /v1/entries", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		res := miniflux.EntryResultSet{}
+		for _, e := range f.entries {
+			if r.URL.Query().Get("status") == miniflux.EntryStatusUnread && e.Status != miniflux.EntryStatusUnread {
+				continue
+			}
+			if r.URL.Query().Get("starred") == miniflux.FilterOnlyStarred && !e.Starred {
+				continue
+			}
+			res.Entries = append(res.Entries, e)
+		}
+		res.Total = len(res.Entries)
+
+		json.NewEncoder(w).Encode(res)
+	})
+
+	mux.HandleFunc("PUT /v1/entries", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		var body struct {
+			EntryIDs []int64 `json:"entry_ids"`
+			Status   string  `json:"status"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		for _, id := range body.EntryIDs {
+			f.entries[id].Status = body.Status
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /v1/entries/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		json.NewEncoder(w).Encode(f.entries[id])
+	})
+
+	mux.HandleFunc("PUT /v1/entries/{id}/star", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		f.entries[id].Starred = !f.entries[id].Starred
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+func itemsByLink(t *testing.T, s store.Store) map[string]store.Item {
+	t.Helper()
+
+	its, err := s.GetAllItems("asc")
+	test.HandleError(t, err)
+
+	m := map[string]store.Item{}
+	for _, it := range its {
+		m[it.Link] = it
+	}
+	return m
+}
+
+func TestMinifluxSync(t *testing.T) {
+	fake := newFakeMiniflux()
+	srv := httptest.NewServer(fake.handler())
+	defer srv.Close()
+
+	backend := config.MinifluxBackend{Host: srv.URL, APIKey: "key", Sync: true}
+	cfg := &config.Config{
+		Feeds:    []config.Feed{{URL: minifluxFeedURL, Backend: backend.ID()}},
+		Backends: &config.Backends{Miniflux: []config.MinifluxBackend{backend}},
+	}
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+
+	_, newItems, errs := c.syncBackends()
+	test.Equal(t, 3, len(newItems), "expected every entry to be new on the first sync")
+	test.Equal(t, 0, len(errs), "unexpected sync errors")
+
+	items := itemsByLink(t, s)
+	test.Equal(t, 3, len(items), "entries not pulled")
+	test.Equal(t, false, items["https://example.com/1"].Read(), "entry should be unread")
+
+	// local changes are pushed
+	test.HandleError(t, s.ToggleRead(items["https://example.com/1"].ID))
+	test.HandleError(t, s.ToggleFavourite(items["https://example.com/2"].ID))
+
+	// remote changes are pulled
+	fake.mu.Lock()
+	fake.entries[3].Status = miniflux.EntryStatusRead
+	fake.mu.Unlock()
+
+	_, newItems, errs = c.syncBackends()
+	test.Equal(t, 0, len(newItems), "expected nothing new on the second sync")
+	test.Equal(t, 0, len(errs), "unexpected sync errors")
+
+	test.Equal(t, miniflux.EntryStatusRead, fake.entries[1].Status, "read not pushed")
+	test.Equal(t, true, fake.entries[2].Starred, "star not pushed")
+
+	items = itemsByLink(t, s)
+	test.Equal(t, true, items["https://example.com/1"].Read(), "local read lost")
+	test.Equal(t, true, items["https://example.com/2"].Favourite, "local favourite lost")
+	test.Equal(t, true, items["https://example.com/3"].Read(), "remote read not pulled")
+
+	remotes, err := s.GetRemoteItems(backend.ID())
+	test.HandleError(t, err)
+	for _, r := range remotes {
+		test.Equal(t, false, r.Dirty(), "changes should be clean after sync")
+	}
+}
+
+func TestSyncRunsRules(t *testing.T) {
+	fake := newFakeMiniflux()
+	srv := httptest.NewServer(fake.handler())
+	defer srv.Close()
+
+	backend := config.MinifluxBackend{Host: srv.URL, APIKey: "key", Sync: true}
+	cfg := &config.Config{
+		Feeds:    []config.Feed{{URL: minifluxFeedURL, Backend: backend.ID()}},
+		Backends: &config.Backends{Miniflux: []config.MinifluxBackend{backend}},
+		Rules:    []config.Rule{{Title: "entry 2", Label: "two"}},
+	}
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+
+	_, errs, err := c.fetchFeeds(nil, true)
+	test.HandleError(t, err)
+	test.Equal(t, 0, len(errs), "unexpected sync errors")
+
+	items := itemsByLink(t, s)
+	test.Equal(t, 1, len(items["https://example.com/2"].Labels), "expected rules to run over synced items")
+	test.Equal(t, 0, len(items["https://example.com/1"].Labels), "expected other items to be left alone")
+}
+
+func TestSyncSkipsPrunedItems(t *testing.T) {
+	fake := newFakeMiniflux()
+	srv := httptest.NewServer(fake.handler())
+	defer srv.Close()
+
+	backend := config.MinifluxBackend{Host: srv.URL, APIKey: "key", Sync: true}
+	cfg := &config.Config{
+		Feeds:    []config.Feed{{URL: minifluxFeedURL, Backend: backend.ID()}},
+		Backends: &config.Backends{Miniflux: []config.MinifluxBackend{backend}},
+	}
+
+	s, err := store.NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+	c := New(cfg, s)
+
+	_, _, errs := c.syncBackends()
+	test.Equal(t, 0, len(errs), "unexpected sync errors")
+
+	pruned := itemsByLink(t, s)["https://example.com/1"]
+	test.HandleError(t, s.DeleteItems([]int{pruned.ID}))
+
+	_, newItems, errs := c.syncBackends()
+	test.Equal(t, 0, len(errs), "unexpected sync errors")
+	test.Equal(t, 0, len(newItems), "pruned entry shouldn't be stored again")
+
+	_, ok := itemsByLink(t, s)["https://example.com/1"]
+	test.Equal(t, false, ok, "pruned entry shouldn't come back")
+}
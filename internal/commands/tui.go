@@ -35,11 +35,17 @@ type TUIItem struct {
 	ID        int
 	Read      bool
 	Favourite bool
-	Tags      []string
+	// Updated is set when the article changed since it was read
+	Updated bool
+	Tags    []string
 }
 
+// filterIDPrefix marks the trailing ID segment of a filter value, see
+// Filterer.GetItem
+const filterIDPrefix = "\x00"
+
 func (i TUIItem) FilterValue() string {
-	return fmt.Sprintf("%s||%s||%s", i.Title, i.FeedName, strings.Join(i.Tags, "||"))
+	return fmt.Sprintf("%s||%s||%s||%s%d", i.Title, i.FeedName, strings.Join(i.Tags, "||"), filterIDPrefix, i.ID)
 }
 
 type model struct {
@@ -53,6 +59,14 @@ type model struct {
 	lastRead        *list.Item
 	lastReadIndex   int
 	refreshing      bool
+	showHealth      bool
+	// search is the name of the saved search the list is narrowed to
+	search       string
+	showSearches bool
+	searches     []searchCount
+	searchIndex  int
+	// showDiff swaps the article in the viewport for its latest changes
+	showDiff bool
 }
 
 func (m model) Init() tea.Cmd {
@@ -75,6 +89,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if m.showHealth {
+		return updateHealth(msg, m)
+	}
+
+	if m.showSearches {
+		return updateSearches(msg, m)
+	}
+
 	if m.selectedArticle != nil {
 		return updateViewport(msg, m)
 	}
@@ -85,7 +107,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) View() string {
 	var s string
 
-	if m.selectedArticle == nil {
+	if m.showHealth {
+		s = healthView(m)
+	} else if m.showSearches {
+		s = searchesView(m)
+	} else if m.selectedArticle == nil {
 		s = listView(m)
 	} else {
 		s = viewportView(m)
@@ -97,6 +123,11 @@ func (m model) View() string {
 func (m model) OpenLink(url string) tea.Cmd {
 	hasOpener := false
 	for _, o := range m.cfg.Openers {
+		// enclosure openers are only used by PlayEnclosure
+		if o.Enclosures {
+			continue
+		}
+
 		match, err := regexp.MatchString(o.Regex, url)
 		if err != nil {
 			log.Printf("[tui.go] OpenLink: invalid regex pattern: %v", err)
@@ -105,26 +136,7 @@ func (m model) OpenLink(url string) tea.Cmd {
 
 		if match {
 			hasOpener = true
-			cmdStr := fmt.Sprintf(o.Cmd, url)
-			parts := strings.Fields(cmdStr)
-			cmd := exec.Command(parts[0], parts[1:]...)
-
-			if o.Takeover {
-				return tea.ExecProcess(cmd, func(err error) tea.Msg {
-					log.Println("OpenLink: takeover exec:", err)
-					return nil
-				})
-			} else {
-				return func() tea.Msg {
-					if err := cmd.Run(); err != nil {
-						log.Println("OpenLink: exec: ", err)
-						return statusUpdate{
-							status: err.Error(),
-						}
-					}
-					return nil
-				}
-			}
+			return runOpener(o, url)
 		}
 	}
 
@@ -139,6 +151,51 @@ func (m model) OpenLink(url string) tea.Cmd {
 	return nil
 }
 
+// PlayEnclosure hands an enclosure to the first enclosure opener matching its
+// URL or MIME type, falling back to OpenLink
+func (m model) PlayEnclosure(e store.Enclosure) tea.Cmd {
+	for _, o := range m.cfg.Openers {
+		if !o.Enclosures {
+			continue
+		}
+
+		re, err := regexp.Compile(o.Regex)
+		if err != nil {
+			log.Printf("[tui.go] PlayEnclosure: invalid regex pattern: %v", err)
+			continue
+		}
+
+		if re.MatchString(e.URL) || (e.Type != "" && re.MatchString(e.Type)) {
+			return runOpener(o, e.URL)
+		}
+	}
+
+	return m.OpenLink(e.URL)
+}
+
+func runOpener(o config.Opener, url string) tea.Cmd {
+	cmdStr := fmt.Sprintf(o.Cmd, url)
+	parts := strings.Fields(cmdStr)
+	cmd := exec.Command(parts[0], parts[1:]...)
+
+	if o.Takeover {
+		return tea.ExecProcess(cmd, func(err error) tea.Msg {
+			log.Println("OpenLink: takeover exec:", err)
+			return nil
+		})
+	}
+
+	return func() tea.Msg {
+		if err := cmd.Run(); err != nil {
+			log.Println("OpenLink: exec: ", err)
+			return statusUpdate{
+				status: err.Error(),
+			}
+		}
+		return nil
+	}
+}
+
 func (m model) OpenInBrowser(url string) error {
 	var cmd string
 	var args []string
@@ -168,13 +225,19 @@ func (m model) OpenInBrowser(url string) error {
 }
 
 func ItemToTUIItem(i store.Item) TUIItem {
+	feedName := i.FeedName
+	if len(i.Sources) > 1 {
+		feedName = strings.Join(i.Sources, ", ")
+	}
+
 	return TUIItem{
 		ID:        i.ID,
-		FeedName:  i.FeedName,
+		FeedName:  feedName,
 		Title:     i.Title,
 		URL:       i.Link,
 		Read:      i.Read(),
 		Favourite: i.Favourite,
+		Updated:   i.Updated(),
 		Tags:      i.Tags,
 	}
 }
@@ -250,7 +313,7 @@ func Render(items []list.Item, cmds *Commands, errors []string, cfg *config.Conf
 
 	l.FilterInput.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(cfg.Theme.FilterColor))
 
-	l.Filter = CustomFilter(*cfg)
+	l.Filter = CustomFilter(*cfg, cmds.store)
 
 	ListKeyMap.SetOverrides(&l)
 
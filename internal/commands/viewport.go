@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"log"
+
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -18,6 +20,27 @@ func updateViewport(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 
 	case tea.ResumeMsg:
 		return m, nil
+	case fullTextDone:
+		if msg.err != nil {
+			log.Println(msg.err)
+			return m, m.list.NewStatusMessage("Error fetching full text")
+		}
+
+		// the reader may have moved on while it was fetched
+		if *m.selectedArticle != msg.id {
+			return m, nil
+		}
+
+		content, err := m.commands.GetGlamourisedArticle(msg.id)
+		if err != nil {
+			m.selectedArticle = nil
+			return m, m.list.NewStatusMessage("Error rendering article")
+		}
+
+		m.showDiff = false
+		m.viewport.SetContent(content)
+		m.viewport.GotoTop()
+		return m, nil
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, ViewportKeyMap.Suspend):
@@ -37,6 +60,7 @@ func updateViewport(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 			}
 
 			m.selectedArticle = nil
+			m.showDiff = false
 			cmds = append(cmds, m.UpdateList())
 
 		case key.Matches(msg, ViewportKeyMap.OpenInBrowser):
@@ -73,6 +97,46 @@ func updateViewport(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 				cmds = append(cmds, cmd)
 			}
 
+		case key.Matches(msg, ViewportKeyMap.Play):
+			current, err := m.commands.store.GetItemByID(*m.selectedArticle)
+			if err != nil {
+				m.selectedArticle = nil
+				return m, m.list.NewStatusMessage("Error: failed to get article")
+			}
+
+			e, ok := playableEnclosure(current.Enclosures)
+			if !ok {
+				return m, m.list.NewStatusMessage("No enclosures to play")
+			}
+
+			cmds = append(cmds, m.PlayEnclosure(e))
+
+		case key.Matches(msg, ViewportKeyMap.FullText):
+			id := *m.selectedArticle
+			c := m.commands
+			cmds = append(cmds, func() tea.Msg {
+				return fullTextDone{id: id, err: c.FetchFullText(id)}
+			})
+
+		case key.Matches(msg, ViewportKeyMap.Diff):
+			m.showDiff = !m.showDiff
+
+			var content string
+			var err error
+			if m.showDiff {
+				content, err = m.commands.GetArticleDiff(*m.selectedArticle, m.viewport.Width)
+			} else {
+				content, err = m.commands.GetGlamourisedArticle(*m.selectedArticle)
+			}
+			if err != nil {
+				m.selectedArticle = nil
+				m.showDiff = false
+				return m, m.list.NewStatusMessage("Error rendering article")
+			}
+
+			m.viewport.SetContent(content)
+			m.viewport.GotoTop()
+
 		case key.Matches(msg, ViewportKeyMap.Prev):
 			navIndex := m.getPrevIndex()
 			items := m.list.Items()
@@ -84,6 +148,7 @@ func updateViewport(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 			item := items[navIndex]
 			id := item.(TUIItem).ID
 			m.selectedArticle = &id
+			m.showDiff = false
 
 			content, err := m.commands.GetGlamourisedArticle(*m.selectedArticle)
 			if err != nil {
@@ -108,6 +173,7 @@ func updateViewport(msg tea.Msg, m model) (tea.Model, tea.Cmd) {
 			item := items[navIndex]
 			id := item.(TUIItem).ID
 			m.selectedArticle = &id
+			m.showDiff = false
 
 			content, err := m.commands.GetGlamourisedArticle(*m.selectedArticle)
 			if err != nil {
@@ -233,6 +299,7 @@ func markRead(m *model) tea.Cmd {
 	}
 
 	// trigger refresh to update read indication
+	m.showDiff = false
 	content, err := m.commands.GetGlamourisedArticle(*m.selectedArticle)
 	if err != nil {
 		m.selectedArticle = nil
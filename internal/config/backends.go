@@ -1,30 +1,66 @@
 package config
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
+	"slices"
 	"strings"
+	"sync"
 
 	miniflux "miniflux.app/v2/client"
+
+	"github.com/guyfedwards/nom/v2/internal/greader"
 )
 
+type Backends struct {
+	Miniflux []MinifluxBackend `yaml:"miniflux,omitempty"`
+	FreshRSS []FreshRSSBackend `yaml:"freshrss,omitempty"`
+	GReader  []GReaderBackend  `yaml:"greader,omitempty"`
+}
+
+// redactedSecret stands in for credentials when the config is shown
+const redactedSecret = "********"
+
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redactedSecret
+}
+
+// Redacted returns a copy of the backends with their credentials hidden
+func (b *Backends) Redacted() *Backends {
+	if b == nil {
+		return nil
+	}
+
+	r := Backends{
+		Miniflux: slices.Clone(b.Miniflux),
+		FreshRSS: slices.Clone(b.FreshRSS),
+		GReader:  slices.Clone(b.GReader),
+	}
+	for i := range r.Miniflux {
+		r.Miniflux[i].APIKey = redact(r.Miniflux[i].APIKey)
+	}
+	for i := range r.FreshRSS {
+		r.FreshRSS[i].Password = redact(r.FreshRSS[i].Password)
+	}
+	for i := range r.GReader {
+		r.GReader[i].Password = redact(r.GReader[i].Password)
+	}
+
+	return &r
+}
+
 type MinifluxBackend struct {
 	Host   string `yaml:"host"`
 	APIKey string `yaml:"api_key"`
+	// Sync pulls entries through the Miniflux API and keeps read and starred
+	// state in step, rather than fetching the feeds directly
+	Sync bool `yaml:"sync,omitempty"`
 }
 
-type FreshRSSBackend struct {
-	Host       string `yaml:"host"`
-	User       string `yaml:"user"`
-	Password   string `yaml:"password"`
-	PrefixCats bool   `yaml:"prefixCats"`
-}
-
-type Backends struct {
-	Miniflux []MinifluxBackend `yaml:"miniflux,omitempty"`
-	FreshRSS []FreshRSSBackend `yaml:"freshrss,omitempty"`
+// ID identifies the backend in Feed.Backend and the store
+func (mfb MinifluxBackend) ID() string {
+	return "miniflux:" + mfb.Host
 }
 
 func (mfb *MinifluxBackend) GetFeeds() ([]Feed, error) {
@@ -39,88 +75,97 @@ func (mfb *MinifluxBackend) GetFeeds() ([]Feed, error) {
 	var ret []Feed
 
 	for _, f := range feeds {
-		ret = append(ret, Feed{URL: f.FeedURL, Name: f.Title, Tags: []string{f.Category.Title}})
+		ret = append(ret, Feed{URL: f.FeedURL, Name: f.Title, Tags: []string{f.Category.Title}, Backend: mfb.ID()})
 	}
 
 	return ret, nil
 }
 
-type FreshRSSResponse struct {
-	Subscriptions []FreshRSSFeed `yaml:"subscriptions,omitempty"`
-}
-
-type Cat struct {
-	Label string `yaml:"label,omitempty"`
-}
-
-type FreshRSSFeed struct {
-	URL        string `yaml:"url,omitempty"`
-	Categories []Cat  `yaml:"categories,omitempty"`
+type FreshRSSBackend struct {
+	Host       string `yaml:"host"`
+	User       string `yaml:"user"`
+	Password   string `yaml:"password"`
+	PrefixCats bool   `yaml:"prefixCats"`
+	Sync       bool   `yaml:"sync,omitempty"`
 }
 
-func (frss FreshRSSFeed) GetCats() string {
-	var ret strings.Builder
-	for i, v := range frss.Categories {
-		if i != 0 {
-			ret.WriteByte(',')
-		}
-		ret.WriteString(v.Label)
+// GReader returns the equivalent Google Reader API backend, which is what
+// FreshRSS exposes
+func (frp FreshRSSBackend) GReader() GReaderBackend {
+	return GReaderBackend{
+		URL:        strings.TrimSuffix(frp.Host, "/") + "/api/greader.php",
+		User:       frp.User,
+		Password:   frp.Password,
+		PrefixCats: frp.PrefixCats,
+		Sync:       frp.Sync,
 	}
-	return ret.String()
 }
 
 func (frp *FreshRSSBackend) GetFeeds() ([]Feed, error) {
-	resp, err := http.Get(fmt.Sprintf("%v/api/greader.php/accounts/ClientLogin?Email=%v&Passwd=%v", frp.Host, frp.User, frp.Password))
-	if err != nil {
-		return []Feed{}, err
-	}
+	grb := frp.GReader()
+	return grb.GetFeeds()
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return []Feed{}, fmt.Errorf("could not login to freshrss, statusCode: %d", resp.StatusCode)
-	}
+// GReaderBackend is any server implementing the Google Reader API
+type GReaderBackend struct {
+	// URL is the root of the API, e.g. https://example.com/api/greader.php
+	URL        string `yaml:"url"`
+	User       string `yaml:"user"`
+	Password   string `yaml:"password"`
+	PrefixCats bool   `yaml:"prefixCats"`
+	// Sync pulls items through the API and keeps read and starred state in
+	// step, rather than fetching the feeds directly
+	Sync bool `yaml:"sync,omitempty"`
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return []Feed{}, err
-	}
+// ID identifies the backend in Feed.Backend and the store
+func (grb GReaderBackend) ID() string {
+	return "greader:" + grb.User + "@" + grb.URL
+}
 
-	// response is text containing the authorization pair
-	lines := strings.Split(string(body), "\n")
-	kv := strings.Split(lines[0], "=")
+// greaderClients keeps one client per server and credentials so that the
+// auth token is reused every time the config is loaded
+var greaderClients sync.Map
 
-	url := fmt.Sprintf("%v/api/greader.php/reader/api/0/subscription/list?output=json", frp.Host)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return []Feed{}, err
+func (grb GReaderBackend) Client() *greader.Client {
+	key := grb.URL + "\x00" + grb.User + "\x00" + grb.Password
+	if c, ok := greaderClients.Load(key); ok {
+		return c.(*greader.Client)
 	}
 
-	req.Header.Add("Authorization", fmt.Sprintf("GoogleLogin auth=%v", kv[1]))
+	c := greader.New(grb.URL, grb.User, grb.Password)
+	c.TokenFile = greader.DefaultTokenFile(grb.URL, grb.User)
 
-	resp, err = http.DefaultClient.Do(req)
-	if err != nil {
-		return []Feed{}, err
-	}
+	actual, _ := greaderClients.LoadOrStore(key, c)
+	return actual.(*greader.Client)
+}
 
-	body, err = io.ReadAll(resp.Body)
-	if err != nil {
-		return []Feed{}, err
+func getCats(s greader.Subscription) string {
+	var ret strings.Builder
+	for i, v := range s.Categories {
+		if i != 0 {
+			ret.WriteByte(',')
+		}
+		ret.WriteString(v.Label)
 	}
+	return ret.String()
+}
 
-	var b FreshRSSResponse
-	err = json.Unmarshal(body, &b)
+func (grb *GReaderBackend) GetFeeds() ([]Feed, error) {
+	subs, err := grb.Client().SubscriptionList()
 	if err != nil {
 		return []Feed{}, err
 	}
 
 	var ret []Feed
 
-	for _, f := range b.Subscriptions {
+	for _, f := range subs {
 		name := ""
-		if frp.PrefixCats {
-			name = f.GetCats()
+		if grb.PrefixCats {
+			name = getCats(f)
 		}
 
-		ret = append(ret, Feed{URL: f.URL, Name: name})
+		ret = append(ret, Feed{URL: f.URL, Name: name, Backend: grb.ID()})
 	}
 
 	return ret, nil
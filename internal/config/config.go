@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -14,23 +16,48 @@ import (
 )
 
 var (
-	ErrFeedAlreadyExists  = errors.New("config.AddFeed: feed already exists")
-	ErrOutdatedConfigV3   = errors.New("outdated config, see docs for v3 changes")
-	DefaultConfigDirName  = "nom"
-	DefaultConfigFileName = "config.yml"
-	DefaultDatabaseName   = "nom.db"
+	ErrFeedAlreadyExists    = errors.New("config.AddFeed: feed already exists")
+	ErrOutdatedConfigV3     = errors.New("outdated config, see docs for v3 changes")
+	ErrSearchNotFound       = errors.New("config.GetSearch: no saved search with that name")
+	DefaultConfigDirName    = "nom"
+	DefaultConfigFileName   = "config.yml"
+	DefaultDatabaseName     = "nom.db"
+	DefaultFailureThreshold = 3
 )
 
 type Feed struct {
 	URL  string   `yaml:"url"`
 	Name string   `yaml:"name,omitempty"`
 	Tags []string `yaml:"tags,omitempty"`
+	// FullText fetches the article from each item's link, for feeds that
+	// only carry a summary
+	FullText bool `yaml:"fulltext,omitempty"`
+	// HTTP overrides the global http options for this feed
+	HTTP *HTTPOptions `yaml:"http,omitempty"`
+	// Interval fixes how often the feed is fetched, e.g. "6h" or "1d",
+	// instead of working it out from the feed
+	Interval string `yaml:"interval,omitempty"`
+	// Backend is the ID of the backend the feed was loaded from, if any
+	Backend string `yaml:"-"`
+}
+
+// IntervalDuration returns the feed's fixed fetch interval, or 0 if it has
+// none
+func (f Feed) IntervalDuration() time.Duration {
+	d, err := ParseDuration(f.Interval)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
 }
 
 type Opener struct {
 	Regex    string `yaml:"regex"`
 	Cmd      string `yaml:"cmd"`
 	Takeover bool   `yaml:"takeover"`
+	// Enclosures openers play podcast and video enclosures rather than
+	// opening links, matching the regex against the URL or MIME type
+	Enclosures bool `yaml:"enclosures,omitempty"`
 }
 
 type Theme struct {
@@ -40,6 +67,19 @@ type Theme struct {
 	FilterColor       string `yaml:"filterColor,omitempty"`
 	SelectedItemColor string `yaml:"selectedItemColor,omitempty"`
 	ReadIcon          string `yaml:"readIcon,omitempty"`
+	UpdatedIcon       string `yaml:"updatedIcon,omitempty"`
+}
+
+// FeverConfig holds the credentials Fever API clients log in with
+type FeverConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// Search is a named filter query, shown as a virtual feed
+type Search struct {
+	Name  string `yaml:"name"`
+	Query string `yaml:"query"`
 }
 
 type FilterConfig struct {
@@ -66,6 +106,16 @@ type Config struct {
 	Theme           Theme        `yaml:"theme,omitempty"`
 	HTTPOptions     *HTTPOptions `yaml:"http,omitempty"`
 	RefreshInterval int          `yaml:"refreshinterval,omitempty"`
+	Retention       *Retention   `yaml:"retention,omitempty"`
+	// FailureThreshold is the number of failed fetches in a row after which
+	// a feed is reported as failing
+	FailureThreshold int            `yaml:"failurethreshold,omitempty"`
+	Fever            *FeverConfig   `yaml:"fever,omitempty"`
+	Searches         []Search       `yaml:"searches,omitempty"`
+	Rules            []Rule         `yaml:"rules,omitempty"`
+	Fetch            *FetchOptions  `yaml:"fetch,omitempty"`
+	Daemon           *DaemonOptions `yaml:"daemon,omitempty"`
+	Notifications    []Notification `yaml:"notifications,omitempty"`
 }
 
 var DefaultTheme = Theme{
@@ -75,6 +125,7 @@ var DefaultTheme = Theme{
 	TitleColorFg:      "231",
 	FilterColor:       "62",
 	ReadIcon:          "\u2713",
+	UpdatedIcon:       "\u21bb",
 }
 
 func (c *Config) ToggleShowRead() {
@@ -126,15 +177,16 @@ func New(configPath string, pager string, previewFeeds []string, version string)
 	}
 
 	return &Config{
-		ConfigPath:      configPath,
-		ConfigDir:       configDir,
-		Pager:           pager,
-		Database:        DefaultDatabaseName,
-		Feeds:           []Feed{},
-		PreviewFeeds:    f,
-		Theme:           DefaultTheme,
-		RefreshInterval: 0,
-		Ordering:        constants.DefaultOrdering,
+		ConfigPath:       configPath,
+		ConfigDir:        configDir,
+		Pager:            pager,
+		Database:         DefaultDatabaseName,
+		Feeds:            []Feed{},
+		PreviewFeeds:     f,
+		Theme:            DefaultTheme,
+		RefreshInterval:  0,
+		FailureThreshold: DefaultFailureThreshold,
+		Ordering:         constants.DefaultOrdering,
 		Filtering: FilterConfig{
 			DefaultIncludeFeedName: false,
 		},
@@ -180,12 +232,55 @@ func (c *Config) Load() error {
 	c.ShowFavourites = fileConfig.ShowFavourites
 	c.Filtering = fileConfig.Filtering
 	c.RefreshInterval = fileConfig.RefreshInterval
+	if fileConfig.FailureThreshold > 0 {
+		c.FailureThreshold = fileConfig.FailureThreshold
+	}
 
 	if fileConfig.HTTPOptions != nil {
-		if _, err := TLSVersion(fileConfig.HTTPOptions.MinTLSVersion); err != nil {
+		if err := fileConfig.HTTPOptions.Validate(); err != nil {
 			return err
 		}
-		c.HTTPOptions = fileConfig.HTTPOptions
+		// mintls stays at the default unless it's set
+		c.HTTPOptions = c.HTTPOptions.Merge(fileConfig.HTTPOptions)
+	}
+
+	for _, f := range fileConfig.Feeds {
+		if err := f.HTTP.Validate(); err != nil {
+			return fmt.Errorf("config.Load: %s: %w", f.URL, err)
+		}
+		if f.Interval != "" {
+			if d, err := ParseDuration(f.Interval); err != nil || d <= 0 {
+				return fmt.Errorf("config.Load: %s: invalid interval %q", f.URL, f.Interval)
+			}
+		}
+	}
+
+	if err := fileConfig.Fetch.Validate(); err != nil {
+		return fmt.Errorf("config.Load: %w", err)
+	}
+	c.Fetch = fileConfig.Fetch
+	c.Daemon = fileConfig.Daemon
+
+	for _, n := range fileConfig.Notifications {
+		if err := n.Validate(); err != nil {
+			return fmt.Errorf("config.Load: %w", err)
+		}
+	}
+	c.Notifications = fileConfig.Notifications
+
+	c.Fever = fileConfig.Fever
+	c.Searches = fileConfig.Searches
+
+	c.Rules = fileConfig.Rules
+	if _, err := c.CompileRules(); err != nil {
+		return fmt.Errorf("config.Load: %w", err)
+	}
+
+	if fileConfig.Retention != nil {
+		if _, err := fileConfig.Retention.MaxAgeDuration(); err != nil {
+			return fmt.Errorf("config.Load: %w", err)
+		}
+		c.Retention = fileConfig.Retention
 	}
 
 	if len(fileConfig.Ordering) > 0 {
@@ -196,6 +291,10 @@ func (c *Config) Load() error {
 		c.Theme.ReadIcon = fileConfig.Theme.ReadIcon
 	}
 
+	if len(fileConfig.Theme.UpdatedIcon) > 0 {
+		c.Theme.UpdatedIcon = fileConfig.Theme.UpdatedIcon
+	}
+
 	if fileConfig.Theme.Glamour != "" {
 		c.Theme.Glamour = fileConfig.Theme.Glamour
 	}
@@ -222,6 +321,7 @@ func (c *Config) Load() error {
 		c.Pager = fileConfig.Pager
 	}
 
+	c.Backends = fileConfig.Backends
 	if fileConfig.Backends != nil {
 		if len(fileConfig.Backends.Miniflux) > 0 {
 			for _, be := range fileConfig.Backends.Miniflux {
@@ -244,11 +344,44 @@ func (c *Config) Load() error {
 				c.Feeds = append(c.Feeds, freshfeeds...)
 			}
 		}
+
+		for _, be := range fileConfig.Backends.GReader {
+			grfeeds, err := be.GetFeeds()
+			if err != nil {
+				return err
+			}
+
+			c.Feeds = append(c.Feeds, grfeeds...)
+		}
 	}
 
 	return nil
 }
 
+// Redacted returns a copy of the config with credentials hidden, for showing
+// it
+func (c *Config) Redacted() *Config {
+	r := *c
+	r.Backends = c.Backends.Redacted()
+	if c.Fever != nil {
+		fever := *c.Fever
+		fever.Password = redact(fever.Password)
+		r.Fever = &fever
+	}
+
+	r.HTTPOptions = c.HTTPOptions.Redacted()
+	r.Feeds = slices.Clone(c.Feeds)
+	for i := range r.Feeds {
+		r.Feeds[i].HTTP = r.Feeds[i].HTTP.Redacted()
+	}
+	r.Notifications = slices.Clone(c.Notifications)
+	for i := range r.Notifications {
+		r.Notifications[i] = r.Notifications[i].Redacted()
+	}
+
+	return &r
+}
+
 // Write writes to a config file
 func (c *Config) Write() error {
 	str, err := yaml.Marshal(c)
@@ -294,6 +427,17 @@ func (c *Config) GetFeeds() []Feed {
 	return c.Feeds
 }
 
+// GetSearch returns the saved search with the given name, ignoring case
+func (c *Config) GetSearch(name string) (Search, error) {
+	for _, s := range c.Searches {
+		if strings.EqualFold(s.Name, name) {
+			return s, nil
+		}
+	}
+
+	return Search{}, ErrSearchNotFound
+}
+
 func (c *Config) setupConfigDir() error {
 	_, err := os.Stat(c.ConfigPath)
 
@@ -331,5 +475,6 @@ const errorPrefix = "cannot unmarshal !!map into "
 // somewhat hacky check for a parsing error on the config.backends node
 func isBackendArrayError(e error) bool {
 	return strings.Contains(e.Error(), errorPrefix+"[]config.FreshRSSBackend") ||
-		strings.Contains(e.Error(), errorPrefix+"[]config.MinifluxBackend")
+		strings.Contains(e.Error(), errorPrefix+"[]config.MinifluxBackend") ||
+		strings.Contains(e.Error(), errorPrefix+"[]config.GReaderBackend")
 }
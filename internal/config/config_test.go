@@ -1,9 +1,13 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -159,3 +163,192 @@ func TestConfigSetupDir(t *testing.T) {
 
 	cleanup()
 }
+
+func TestParseDuration(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"90m":  90 * time.Minute,
+		"30d":  30 * 24 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+	} {
+		d, err := ParseDuration(in)
+		test.HandleError(t, err)
+		test.Equal(t, want, d, "wrong duration for "+in)
+	}
+
+	_, err := ParseDuration("soon")
+	if err == nil {
+		t.Fatalf("expected error for invalid duration")
+	}
+}
+
+func TestRetentionDefaults(t *testing.T) {
+	var c Config
+	err := yaml.Unmarshal([]byte("retention:\n  maxItems: 10\n"), &c)
+	test.HandleError(t, err)
+
+	test.Equal(t, 10, c.Retention.MaxItems, "maxItems not parsed")
+	test.Equal(t, true, c.Retention.KeepUnread, "unread should be kept by default")
+	test.Equal(t, true, c.Retention.KeepFavourites, "favourites should be kept by default")
+
+	err = yaml.Unmarshal([]byte("retention:\n  keepUnread: false\n"), &c)
+	test.HandleError(t, err)
+	test.Equal(t, false, c.Retention.KeepUnread, "keepUnread override ignored")
+}
+
+func TestFeedEdits(t *testing.T) {
+	path := t.TempDir() + "/config.yml"
+	err := os.WriteFile(path, []byte(`# my feeds
+feeds:
+  - url: http://a.com/feed # the first one
+    name: A
+    tags: [news]
+  - url: http://b.com/feed
+    name: B
+ordering: desc
+`), 0644)
+	test.HandleError(t, err)
+
+	c, _ := New(path, "", []string{}, "")
+	test.HandleError(t, c.Load())
+
+	test.HandleError(t, c.RenameFeed("B", "Bee"))
+	test.HandleError(t, c.TagFeed("http://b.com/feed", []string{"tech", "go"}))
+	test.HandleError(t, c.UntagFeed("A", []string{"news"}))
+
+	_, err = c.RemoveFeed("nope")
+	if !errors.Is(err, ErrFeedNotFound) {
+		t.Fatalf("expected ErrFeedNotFound, got %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	test.HandleError(t, err)
+	test.Equal(t, `# my feeds
+feeds:
+  - url: http://a.com/feed # the first one
+    name: A
+  - url: http://b.com/feed
+    name: Bee
+    tags:
+      - tech
+      - go
+ordering: desc
+`, string(raw), "unexpected config file")
+
+	test.Equal(t, "Bee", c.Feeds[1].Name, "in memory feeds not updated")
+
+	removed, err := c.RemoveFeed("http://a.com/feed")
+	test.HandleError(t, err)
+	test.Equal(t, "A", removed.Name, "wrong feed removed")
+	test.Equal(t, 1, len(c.Feeds), "feed not removed")
+	test.Equal(t, "http://b.com/feed", c.Feeds[0].URL, "wrong feed left")
+}
+
+func TestHTTPOptionsSecrets(t *testing.T) {
+	t.Setenv("NOM_TEST_TOKEN", "from-env")
+
+	var c Config
+	err := yaml.Unmarshal([]byte(`
+http:
+  timeout: 5s
+  headers:
+    X-Global: one
+feeds:
+- url: https://internal.example.com/feed
+  http:
+    useragent: curl
+    proxy: http://proxy.example.com:3128
+    headers:
+      X-Team: {env: NOM_TEST_TOKEN}
+    auth:
+      username: me
+      password: {cmd: "echo from-cmd"}
+`), &c)
+	test.HandleError(t, err)
+	test.HandleError(t, c.Feeds[0].HTTP.Validate())
+
+	opts := c.FeedHTTPOptions(c.Feeds[0])
+	test.Equal(t, "curl", opts.UserAgent, "expected feed user agent")
+	test.Equal(t, 5*time.Second, opts.TimeoutDuration(), "expected global timeout")
+	test.Equal(t, "one", opts.Headers["X-Global"].Value, "expected global headers to be kept")
+	_, ok := c.HTTPOptions.Headers["X-Team"]
+	test.Equal(t, false, ok, "expected global headers to be left alone")
+
+	v, err := opts.Headers["X-Team"].Resolve()
+	test.HandleError(t, err)
+	test.Equal(t, "from-env", v, "expected header from env")
+
+	v, err = opts.Auth.Password.Resolve()
+	test.HandleError(t, err)
+	test.Equal(t, "from-cmd", v, "expected password from command")
+
+	_, err = Secret{Env: "NOM_TEST_UNSET"}.Resolve()
+	test.Equal(t, true, errors.Is(err, ErrSecretNotSet), "expected unset env to fail")
+
+	// secrets are written back as they were given, not resolved
+	out, err := yaml.Marshal(c.Feeds[0].HTTP.Auth)
+	test.HandleError(t, err)
+	test.Equal(t, "username: me\npassword:\n    cmd: echo from-cmd\n", string(out), "expected secret to round trip")
+
+	test.Equal(t, true, (&HTTPOptions{Timeout: "soon"}).Validate() != nil, "expected invalid timeout to fail")
+	test.Equal(t, true, (&HTTPOptions{MinTLSVersion: "TLS 9"}).Validate() != nil, "expected invalid tls version to fail")
+	test.Equal(t, true, (&HTTPOptions{Headers: map[string]Secret{"X-Key": {Cmd: "  "}}}).Validate() != nil, "expected blank secret cmd to fail")
+
+	_, err = Secret{Cmd: " "}.Resolve()
+	test.Equal(t, true, err != nil, "expected blank secret cmd not to run")
+}
+
+func TestClearSecretCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	test.HandleError(t, os.WriteFile(path, []byte("old\n"), 0600))
+
+	s := Secret{Cmd: "cat " + path}
+	v, err := s.Resolve()
+	test.HandleError(t, err)
+	test.Equal(t, "old", v, "wrong secret")
+
+	test.HandleError(t, os.WriteFile(path, []byte("new\n"), 0600))
+	v, err = s.Resolve()
+	test.HandleError(t, err)
+	test.Equal(t, "old", v, "expected command output to be cached")
+
+	ClearSecretCache()
+	v, err = s.Resolve()
+	test.HandleError(t, err)
+	test.Equal(t, "new", v, "expected command to run again once cleared")
+}
+
+func TestConfigRedacted(t *testing.T) {
+	c := &Config{
+		Backends: &Backends{
+			Miniflux: []MinifluxBackend{{Host: "https://miniflux.example.com", APIKey: "key"}},
+			FreshRSS: []FreshRSSBackend{{Host: "https://freshrss.example.com", User: "me", Password: "fresh"}},
+			GReader:  []GReaderBackend{{URL: "https://greader.example.com", User: "me"}},
+		},
+		Fever:       &FeverConfig{Username: "me", Password: "fever"},
+		HTTPOptions: &HTTPOptions{Headers: map[string]Secret{"X-Api-Key": {Value: "header"}}},
+		Feeds: []Feed{{URL: "https://example.com/feed", HTTP: &HTTPOptions{
+			Cookies: map[string]Secret{"session": {Value: "cookie"}},
+			Auth:    &HTTPAuth{Username: "me", Password: Secret{Value: "basic"}, Token: Secret{Env: "NOM_TOKEN"}},
+		}}},
+		Notifications: []Notification{
+			{Webhook: &Webhook{URL: "https://example.com/hook", Headers: map[string]Secret{"Authorization": {Value: "hook"}}}},
+			{Email: &Email{Host: "smtp.example.com", Password: Secret{Value: "smtp"}}},
+		},
+	}
+
+	out, err := yaml.Marshal(c.Redacted())
+	test.HandleError(t, err)
+	test.Equal(t, true, strings.Contains(string(out), "env: NOM_TOKEN"), "expected where secrets come from to be shown")
+	for _, secret := range []string{"key", "fresh", "fever", "header", "cookie", "basic", "hook", "smtp"} {
+		test.Equal(t, false, strings.Contains(string(out), ": "+secret+"\n"), "expected "+secret+" to be redacted")
+	}
+
+	r := c.Redacted()
+	test.Equal(t, redactedSecret, r.Backends.Miniflux[0].APIKey, "expected api key to be redacted")
+	test.Equal(t, "", r.Backends.GReader[0].Password, "expected an unset password to stay unset")
+	test.Equal(t, "key", c.Backends.Miniflux[0].APIKey, "expected the config itself to be left alone")
+	test.Equal(t, "fever", c.Fever.Password, "expected the config itself to be left alone")
+	test.Equal(t, "cookie", c.Feeds[0].HTTP.Cookies["session"].Value, "expected the config itself to be left alone")
+	test.Equal(t, "smtp", c.Notifications[1].Email.Password.Value, "expected the config itself to be left alone")
+}
@@ -0,0 +1,38 @@
+package config
+
+import "path/filepath"
+
+const (
+	DefaultDaemonPIDFile = "nom.pid"
+	DefaultDaemonLogFile = "daemon.log"
+)
+
+// DaemonOptions are where nom daemon keeps its PID and log files. Relative
+// paths are in the config directory.
+type DaemonOptions struct {
+	PIDFile string `yaml:"pidFile,omitempty"`
+	LogFile string `yaml:"logFile,omitempty"`
+}
+
+// DaemonPIDFile is where a running daemon writes its PID
+func (c *Config) DaemonPIDFile() string {
+	if c.Daemon != nil && c.Daemon.PIDFile != "" {
+		return c.inConfigDir(c.Daemon.PIDFile)
+	}
+	return c.inConfigDir(DefaultDaemonPIDFile)
+}
+
+// DaemonLogFile is where the daemon logs to
+func (c *Config) DaemonLogFile() string {
+	if c.Daemon != nil && c.Daemon.LogFile != "" {
+		return c.inConfigDir(c.Daemon.LogFile)
+	}
+	return c.inConfigDir(DefaultDaemonLogFile)
+}
+
+func (c *Config) inConfigDir(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.ConfigDir, path)
+}
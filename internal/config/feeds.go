@@ -0,0 +1,243 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrFeedNotFound  = errors.New("feed not found in config file")
+	ErrFeedAmbiguous = errors.New("more than one feed matches, use the url")
+)
+
+// editFeeds applies fn to the feeds sequence in the config file and writes it
+// back. The file is edited as a yaml node tree, so comments, ordering and
+// other settings are left as they were.
+func (c *Config) editFeeds(fn func(feeds *yaml.Node) error) error {
+	rawData, err := os.ReadFile(c.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	err = yaml.Unmarshal(rawData, &doc)
+	if err != nil {
+		return err
+	}
+
+	if len(doc.Content) == 0 {
+		return ErrFeedNotFound
+	}
+
+	feeds := mappingValue(doc.Content[0], "feeds")
+	if feeds == nil || feeds.Kind != yaml.SequenceNode {
+		return ErrFeedNotFound
+	}
+
+	err = fn(feeds)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err = enc.Encode(&doc)
+	if err != nil {
+		return err
+	}
+	enc.Close()
+
+	err = os.WriteFile(c.ConfigPath, buf.Bytes(), 0655)
+	if err != nil {
+		return err
+	}
+
+	// keep c.Feeds in step, backend feeds aren't in the file so carry them over
+	var fileFeeds []Feed
+	err = feeds.Decode(&fileFeeds)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range c.Feeds {
+		if f.Backend != "" {
+			fileFeeds = append(fileFeeds, f)
+		}
+	}
+	c.Feeds = fileFeeds
+
+	return nil
+}
+
+// mappingValue returns the value node for key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
+
+func deleteMappingValue(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = slices.Delete(node.Content, i, i+2)
+			return
+		}
+	}
+}
+
+// findFeed returns the index of the feed with ref as its url or name
+func findFeed(feeds *yaml.Node, ref string) (int, error) {
+	found := -1
+
+	for i, f := range feeds.Content {
+		if u := mappingValue(f, "url"); u != nil && u.Value == ref {
+			return i, nil
+		}
+
+		if n := mappingValue(f, "name"); n != nil && n.Value == ref {
+			if found != -1 {
+				return -1, fmt.Errorf("%w: %s", ErrFeedAmbiguous, ref)
+			}
+			found = i
+		}
+	}
+
+	if found == -1 {
+		return -1, fmt.Errorf("%w: %s", ErrFeedNotFound, ref)
+	}
+
+	return found, nil
+}
+
+// RemoveFeed removes the feed with ref as its url or name from the config
+// file and returns it
+func (c *Config) RemoveFeed(ref string) (Feed, error) {
+	var removed Feed
+
+	err := c.editFeeds(func(feeds *yaml.Node) error {
+		i, err := findFeed(feeds, ref)
+		if err != nil {
+			return err
+		}
+
+		err = feeds.Content[i].Decode(&removed)
+		if err != nil {
+			return err
+		}
+
+		feeds.Content = slices.Delete(feeds.Content, i, i+1)
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("config.RemoveFeed: %w", err)
+	}
+
+	return removed, nil
+}
+
+// RenameFeed sets the name of a feed, an empty name removes it
+func (c *Config) RenameFeed(ref string, name string) error {
+	err := c.editFeeds(func(feeds *yaml.Node) error {
+		i, err := findFeed(feeds, ref)
+		if err != nil {
+			return err
+		}
+
+		if name == "" {
+			deleteMappingValue(feeds.Content[i], "name")
+		} else {
+			setMappingValue(feeds.Content[i], "name", &yaml.Node{Kind: yaml.ScalarNode, Value: name})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("config.RenameFeed: %w", err)
+	}
+
+	return nil
+}
+
+// TagFeed adds tags to a feed, skipping ones it already has
+func (c *Config) TagFeed(ref string, tags []string) error {
+	err := c.editFeeds(func(feeds *yaml.Node) error {
+		i, err := findFeed(feeds, ref)
+		if err != nil {
+			return err
+		}
+
+		seq := mappingValue(feeds.Content[i], "tags")
+		if seq == nil || seq.Kind != yaml.SequenceNode {
+			seq = &yaml.Node{Kind: yaml.SequenceNode}
+			setMappingValue(feeds.Content[i], "tags", seq)
+		}
+
+		for _, tag := range tags {
+			has := slices.ContainsFunc(seq.Content, func(n *yaml.Node) bool {
+				return n.Value == tag
+			})
+			if !has {
+				seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: tag})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("config.TagFeed: %w", err)
+	}
+
+	return nil
+}
+
+// UntagFeed removes tags from a feed, and the tags key once none are left
+func (c *Config) UntagFeed(ref string, tags []string) error {
+	err := c.editFeeds(func(feeds *yaml.Node) error {
+		i, err := findFeed(feeds, ref)
+		if err != nil {
+			return err
+		}
+
+		seq := mappingValue(feeds.Content[i], "tags")
+		if seq == nil || seq.Kind != yaml.SequenceNode {
+			return nil
+		}
+
+		seq.Content = slices.DeleteFunc(seq.Content, func(n *yaml.Node) bool {
+			return slices.Contains(tags, n.Value)
+		})
+
+		if len(seq.Content) == 0 {
+			deleteMappingValue(feeds.Content[i], "tags")
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("config.UntagFeed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultFetchWorkers = 8
+	DefaultFetchPerHost = 2
+	DefaultFetchRetries = 2
+	DefaultBackoff      = time.Second
+	DefaultMaxBackoff   = time.Minute
+)
+
+// FetchOptions control how many feeds are fetched at once and how failed
+// fetches are retried. Unset values use the defaults above.
+type FetchOptions struct {
+	// Workers is the number of feeds fetched at once
+	Workers int `yaml:"workers,omitempty"`
+	// PerHost is the number of feeds fetched at once from a single host
+	PerHost int `yaml:"perhost,omitempty"`
+	// Retries is how many times timeouts, 429s and 5xx responses are
+	// retried, 0 uses the default and -1 turns retries off
+	Retries int `yaml:"retries,omitempty"`
+	// Backoff is the wait before the first retry, doubling each time up to
+	// MaxBackoff. A Retry-After from the server is used when given.
+	Backoff    string `yaml:"backoff,omitempty"`
+	MaxBackoff string `yaml:"maxbackoff,omitempty"`
+}
+
+func (f *FetchOptions) Validate() error {
+	if f == nil {
+		return nil
+	}
+
+	for _, d := range []string{f.Backoff, f.MaxBackoff} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d); err != nil {
+			return fmt.Errorf("invalid fetch backoff: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (f *FetchOptions) WorkerCount() int {
+	if f == nil || f.Workers <= 0 {
+		return DefaultFetchWorkers
+	}
+	return f.Workers
+}
+
+func (f *FetchOptions) PerHostLimit() int {
+	if f == nil || f.PerHost <= 0 {
+		return DefaultFetchPerHost
+	}
+	return f.PerHost
+}
+
+func (f *FetchOptions) RetryCount() int {
+	switch {
+	case f == nil || f.Retries == 0:
+		return DefaultFetchRetries
+	case f.Retries < 0:
+		return 0
+	}
+	return f.Retries
+}
+
+func (f *FetchOptions) BackoffDurations() (backoff time.Duration, max time.Duration) {
+	backoff, max = DefaultBackoff, DefaultMaxBackoff
+	if f == nil {
+		return backoff, max
+	}
+
+	if d, err := time.ParseDuration(f.Backoff); err == nil && d > 0 {
+		backoff = d
+	}
+	if d, err := time.ParseDuration(f.MaxBackoff); err == nil && d > 0 {
+		max = d
+	}
+
+	return backoff, max
+}
@@ -3,6 +3,10 @@ package config
 import (
 	"crypto/tls"
 	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+	"time"
 )
 
 // CloudFlare blocks requests unless a minimum TLSVersion is specified.
@@ -13,10 +17,36 @@ var tlsVersions map[string]uint16 = map[string]uint16{
 	"TLS 1.3": tls.VersionTLS13,
 }
 
+// DefaultTimeout is how long a request may take unless a timeout is set
+const DefaultTimeout = 30 * time.Second
+
+// HTTPOptions are set for all feeds under `http`, and can be overridden by
+// each feed
 type HTTPOptions struct {
 	//MinTLSVersion must be set to one of the strings returned by
 	//tls.VersionName. "TLS 1.2" by default.
 	MinTLSVersion string `yaml:"mintls,omitempty"`
+	// Insecure skips verifying the server's certificate
+	Insecure bool `yaml:"insecure,omitempty"`
+	// CACert is the path to a PEM file of certificates to trust, e.g. for an
+	// internal CA
+	CACert string `yaml:"cacert,omitempty"`
+	// Timeout is a duration, e.g. 10s
+	Timeout   string `yaml:"timeout,omitempty"`
+	UserAgent string `yaml:"useragent,omitempty"`
+	// Proxy is the URL of a proxy for these requests, in place of the
+	// HTTP_PROXY and HTTPS_PROXY environment variables
+	Proxy   string            `yaml:"proxy,omitempty"`
+	Headers map[string]Secret `yaml:"headers,omitempty"`
+	Cookies map[string]Secret `yaml:"cookies,omitempty"`
+	Auth    *HTTPAuth         `yaml:"auth,omitempty"`
+}
+
+// HTTPAuth is basic auth when Username is set, otherwise a bearer token
+type HTTPAuth struct {
+	Username string `yaml:"username,omitempty"`
+	Password Secret `yaml:"password,omitempty"`
+	Token    Secret `yaml:"token,omitempty"`
 }
 
 // TLSVersion maps one of a few supported TLS version strings to the corresponding
@@ -27,3 +57,157 @@ func TLSVersion(configStr string) (uint16, error) {
 	}
 	return 0, fmt.Errorf("unsupported tls version: %s", configStr)
 }
+
+// Redacted returns a copy of the options with secrets given in the config
+// file hidden
+func (o *HTTPOptions) Redacted() *HTTPOptions {
+	if o == nil {
+		return nil
+	}
+
+	r := *o
+	r.Headers = redactSecrets(o.Headers)
+	r.Cookies = redactSecrets(o.Cookies)
+	if o.Auth != nil {
+		auth := *o.Auth
+		auth.Password = auth.Password.Redacted()
+		auth.Token = auth.Token.Redacted()
+		r.Auth = &auth
+	}
+	return &r
+}
+
+// Validate checks the options that are parsed when a request is made
+func (o *HTTPOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+
+	if o.MinTLSVersion != "" {
+		if _, err := TLSVersion(o.MinTLSVersion); err != nil {
+			return err
+		}
+	}
+
+	if o.Timeout != "" {
+		if _, err := time.ParseDuration(o.Timeout); err != nil {
+			return fmt.Errorf("invalid http timeout: %w", err)
+		}
+	}
+
+	if o.Proxy != "" {
+		if _, err := url.Parse(o.Proxy); err != nil {
+			return fmt.Errorf("invalid http proxy: %w", err)
+		}
+	}
+
+	for name, secret := range o.Headers {
+		if err := secret.Validate(); err != nil {
+			return fmt.Errorf("invalid http header %s: %w", name, err)
+		}
+	}
+	for name, secret := range o.Cookies {
+		if err := secret.Validate(); err != nil {
+			return fmt.Errorf("invalid http cookie %s: %w", name, err)
+		}
+	}
+	if o.Auth != nil {
+		for _, secret := range []Secret{o.Auth.Password, o.Auth.Token} {
+			if err := secret.Validate(); err != nil {
+				return fmt.Errorf("invalid http auth: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// TimeoutDuration returns Timeout, or DefaultTimeout if it isn't set
+func (o *HTTPOptions) TimeoutDuration() time.Duration {
+	if o == nil || o.Timeout == "" {
+		return DefaultTimeout
+	}
+
+	d, err := time.ParseDuration(o.Timeout)
+	if err != nil {
+		return DefaultTimeout
+	}
+
+	return d
+}
+
+// Merge returns the options with those set in override taking precedence.
+// Headers and cookies are merged by name.
+func (o *HTTPOptions) Merge(override *HTTPOptions) *HTTPOptions {
+	merged := HTTPOptions{}
+	if o != nil {
+		merged = *o
+	}
+	merged.Headers = maps.Clone(merged.Headers)
+	merged.Cookies = maps.Clone(merged.Cookies)
+
+	if override == nil {
+		return &merged
+	}
+
+	if override.MinTLSVersion != "" {
+		merged.MinTLSVersion = override.MinTLSVersion
+	}
+	if override.Insecure {
+		merged.Insecure = true
+	}
+	if override.CACert != "" {
+		merged.CACert = override.CACert
+	}
+	if override.Timeout != "" {
+		merged.Timeout = override.Timeout
+	}
+	if override.UserAgent != "" {
+		merged.UserAgent = override.UserAgent
+	}
+	if override.Proxy != "" {
+		merged.Proxy = override.Proxy
+	}
+	if override.Auth != nil {
+		merged.Auth = override.Auth
+	}
+
+	for k, v := range override.Headers {
+		if merged.Headers == nil {
+			merged.Headers = map[string]Secret{}
+		}
+		merged.Headers[k] = v
+	}
+	for k, v := range override.Cookies {
+		if merged.Cookies == nil {
+			merged.Cookies = map[string]Secret{}
+		}
+		merged.Cookies[k] = v
+	}
+
+	return &merged
+}
+
+// FeedHTTPOptions returns the options for fetching a feed, its own over the
+// global ones
+func (c *Config) FeedHTTPOptions(f Feed) *HTTPOptions {
+	return c.HTTPOptions.Merge(f.HTTP)
+}
+
+// LinkHTTPOptions returns the options for fetching a page or file a feed
+// links to. Auth, headers and cookies are only sent to the feed's own host,
+// so a feed's credentials don't leak to the sites it links to.
+func (c *Config) LinkHTTPOptions(f Feed, link string) *HTTPOptions {
+	opts := c.FeedHTTPOptions(f)
+
+	feedURL, err := url.Parse(f.URL)
+	linkURL, linkErr := url.Parse(link)
+	if err == nil && linkErr == nil && feedURL.Host != "" && strings.EqualFold(feedURL.Host, linkURL.Host) {
+		return opts
+	}
+
+	opts.Auth = nil
+	opts.Headers = nil
+	opts.Cookies = nil
+	return opts
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultNotifyDigest is how many items are sent as separate messages before
+// they're sent as one digest instead
+const DefaultNotifyDigest = 3
+
+// Notification sends new items to a command, a webhook or by email. Items
+// can be narrowed down by feed, tag and a filter query, all of which must
+// match; with none set every new item is sent.
+type Notification struct {
+	Name string `yaml:"name,omitempty"`
+
+	// Feed matches the feed url or name, Tag a tag of the feed and Query is
+	// a filter as typed in the TUI
+	Feed  string `yaml:"feed,omitempty"`
+	Tag   string `yaml:"tag,omitempty"`
+	Query string `yaml:"query,omitempty"`
+
+	// Throttle is the least time between messages. Items found in between
+	// wait and are sent together.
+	Throttle string `yaml:"throttle,omitempty"`
+	// Digest is how many items are sent one by one, more are sent as a
+	// single digest. -1 always sends a digest.
+	Digest int `yaml:"digest,omitempty"`
+
+	// Cmd is run for each message, with each word a Go template
+	Cmd     string   `yaml:"cmd,omitempty"`
+	Webhook *Webhook `yaml:"webhook,omitempty"`
+	Email   *Email   `yaml:"email,omitempty"`
+}
+
+// Webhook is POSTed a JSON payload for each message
+type Webhook struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]Secret `yaml:"headers,omitempty"`
+}
+
+// Email sends each message over SMTP. Port 465 uses TLS throughout, other
+// ports upgrade with STARTTLS when the server offers it.
+type Email struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port,omitempty"`
+	Username string   `yaml:"username,omitempty"`
+	Password Secret   `yaml:"password,omitempty"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// String names the notification in output and in the store, using its
+// filters and target if it has no name
+func (n Notification) String() string {
+	if n.Name != "" {
+		return n.Name
+	}
+
+	var parts []string
+	for _, p := range []struct{ k, v string }{
+		{"feed", n.Feed}, {"tag", n.Tag}, {"query", n.Query}, {"cmd", n.Cmd},
+	} {
+		if p.v != "" {
+			parts = append(parts, p.k+"="+p.v)
+		}
+	}
+	if n.Webhook != nil {
+		parts = append(parts, "webhook="+n.Webhook.URL)
+	}
+	if n.Email != nil {
+		parts = append(parts, "email="+strings.Join(n.Email.To, ","))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Redacted returns a copy of the notification with its secrets hidden
+func (n Notification) Redacted() Notification {
+	if n.Webhook != nil {
+		webhook := *n.Webhook
+		webhook.Headers = redactSecrets(n.Webhook.Headers)
+		n.Webhook = &webhook
+	}
+	if n.Email != nil {
+		email := *n.Email
+		email.Password = email.Password.Redacted()
+		n.Email = &email
+	}
+	return n
+}
+
+func (n Notification) Validate() error {
+	targets := 0
+	if n.Cmd != "" {
+		targets++
+	}
+	if n.Webhook != nil {
+		targets++
+		if u, err := url.Parse(n.Webhook.URL); err != nil || u.Host == "" {
+			return fmt.Errorf("notification %q: invalid webhook url %q", n, n.Webhook.URL)
+		}
+		for name, secret := range n.Webhook.Headers {
+			if err := secret.Validate(); err != nil {
+				return fmt.Errorf("notification %q: invalid header %s: %w", n, name, err)
+			}
+		}
+	}
+	if n.Email != nil {
+		targets++
+		if n.Email.Host == "" || n.Email.From == "" || len(n.Email.To) == 0 {
+			return fmt.Errorf("notification %q: email needs host, from and to", n)
+		}
+		if err := n.Email.Password.Validate(); err != nil {
+			return fmt.Errorf("notification %q: invalid email password: %w", n, err)
+		}
+	}
+	if targets != 1 {
+		return fmt.Errorf("notification %q: needs one of cmd, webhook or email", n)
+	}
+
+	if n.Throttle != "" {
+		if d, err := ParseDuration(n.Throttle); err != nil || d < 0 {
+			return fmt.Errorf("notification %q: invalid throttle %q", n, n.Throttle)
+		}
+	}
+
+	return nil
+}
+
+// ThrottleDuration is the least time between messages, 0 if not throttled
+func (n Notification) ThrottleDuration() time.Duration {
+	d, err := ParseDuration(n.Throttle)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// DigestAfter is how many items are sent one by one
+func (n Notification) DigestAfter() int {
+	switch {
+	case n.Digest < 0:
+		return 0
+	case n.Digest == 0:
+		return DefaultNotifyDigest
+	}
+	return n.Digest
+}
+
+// MatchFeed reports whether items from feed pass the feed and tag filters
+func (n Notification) MatchFeed(feed Feed) bool {
+	if n.Feed != "" && !strings.EqualFold(n.Feed, feed.URL) && !strings.EqualFold(n.Feed, feed.Name) {
+		return false
+	}
+
+	return n.Tag == "" || hasTag(feed.Tags, n.Tag)
+}
+
+// Addr is the SMTP server's host and port, 587 if no port is set
+func (e Email) Addr() string {
+	port := e.Port
+	if port == 0 {
+		port = 587
+	}
+	return net.JoinHostPort(e.Host, strconv.Itoa(port))
+}
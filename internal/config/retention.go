@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Retention controls which items are pruned from the store after each
+// refresh. Unset limits are not applied.
+type Retention struct {
+	// MaxAge is how old an item can get before it is pruned, e.g. "30d"
+	MaxAge string `yaml:"maxAge,omitempty"`
+	// MaxItems is the number of items kept per feed
+	MaxItems       int  `yaml:"maxItems,omitempty"`
+	KeepUnread     bool `yaml:"keepUnread"`
+	KeepFavourites bool `yaml:"keepFavourites"`
+}
+
+// UnmarshalYAML defaults to keeping unread and favourite items so that
+// configuring a limit never deletes those unless asked to.
+func (r *Retention) UnmarshalYAML(value *yaml.Node) error {
+	type plain Retention
+	p := plain{KeepUnread: true, KeepFavourites: true}
+
+	if err := value.Decode(&p); err != nil {
+		return err
+	}
+
+	*r = Retention(p)
+	return nil
+}
+
+// MaxAgeDuration parses MaxAge, 0 means no age limit
+func (r Retention) MaxAgeDuration() (time.Duration, error) {
+	if r.MaxAge == "" {
+		return 0, nil
+	}
+
+	d, err := ParseDuration(r.MaxAge)
+	if err != nil {
+		return 0, fmt.Errorf("retention.maxAge: %w", err)
+	}
+
+	return d, nil
+}
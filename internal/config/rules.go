@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule runs actions on new items matching every condition it sets. Title,
+// Content and Author are regular expressions.
+type Rule struct {
+	Name string `yaml:"name,omitempty"`
+
+	// Feed matches the feed url or name, Tag a tag of the feed
+	Feed    string `yaml:"feed,omitempty"`
+	Tag     string `yaml:"tag,omitempty"`
+	Title   string `yaml:"title,omitempty"`
+	Content string `yaml:"content,omitempty"`
+	Author  string `yaml:"author,omitempty"`
+
+	Read      bool   `yaml:"read,omitempty"`
+	Favourite bool   `yaml:"favourite,omitempty"`
+	Hide      bool   `yaml:"hide,omitempty"`
+	Label     string `yaml:"label,omitempty"`
+	// Cmd is run with the item in NOM_* environment variables
+	Cmd string `yaml:"cmd,omitempty"`
+}
+
+// RuleMatcher is a Rule with its expressions compiled
+type RuleMatcher struct {
+	Rule
+	title   *regexp.Regexp
+	content *regexp.Regexp
+	author  *regexp.Regexp
+}
+
+// String names the rule in output, using its conditions if it has no name
+func (r Rule) String() string {
+	if r.Name != "" {
+		return r.Name
+	}
+
+	var conds []string
+	for _, c := range []struct{ k, v string }{
+		{"feed", r.Feed}, {"tag", r.Tag}, {"title", r.Title}, {"content", r.Content}, {"author", r.Author},
+	} {
+		if c.v != "" {
+			conds = append(conds, c.k+"="+c.v)
+		}
+	}
+
+	return strings.Join(conds, " ")
+}
+
+// Compile checks the rule and compiles its expressions
+func (r Rule) Compile() (RuleMatcher, error) {
+	m := RuleMatcher{Rule: r}
+
+	if r.Feed == "" && r.Tag == "" && r.Title == "" && r.Content == "" && r.Author == "" {
+		return m, fmt.Errorf("rule %q: needs at least one of feed, tag, title, content or author", r.Name)
+	}
+
+	if !r.Read && !r.Favourite && !r.Hide && r.Label == "" && r.Cmd == "" {
+		return m, fmt.Errorf("rule %q: needs at least one of read, favourite, hide, label or cmd", r)
+	}
+
+	for _, e := range []struct {
+		name string
+		expr string
+		re   **regexp.Regexp
+	}{
+		{"title", r.Title, &m.title},
+		{"content", r.Content, &m.content},
+		{"author", r.Author, &m.author},
+	} {
+		if e.expr == "" {
+			continue
+		}
+
+		re, err := regexp.Compile(e.expr)
+		if err != nil {
+			return m, fmt.Errorf("rule %q: invalid %s: %w", r, e.name, err)
+		}
+		*e.re = re
+	}
+
+	return m, nil
+}
+
+// Match reports whether an item from feed with the given title, content and
+// author matches the rule
+func (m RuleMatcher) Match(feed Feed, title string, content string, author string) bool {
+	if m.Feed != "" && !strings.EqualFold(m.Feed, feed.URL) && !strings.EqualFold(m.Feed, feed.Name) {
+		return false
+	}
+
+	if m.Tag != "" && !hasTag(feed.Tags, m.Tag) {
+		return false
+	}
+
+	return (m.title == nil || m.title.MatchString(title)) &&
+		(m.content == nil || m.content.MatchString(content)) &&
+		(m.author == nil || m.author.MatchString(author))
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompileRules compiles every rule in the config
+func (c *Config) CompileRules() ([]RuleMatcher, error) {
+	var ms []RuleMatcher
+	for _, r := range c.Rules {
+		m, err := r.Compile()
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrSecretNotSet is returned when a secret's environment variable is empty
+var ErrSecretNotSet = errors.New("secret not set")
+
+// Secret is a credential given in the config file, read from an environment
+// variable, or printed by a command, e.g. a password manager:
+//
+//	password: hunter2
+//	password: {env: FEED_PASSWORD}
+//	password: {cmd: "pass show feeds/example"}
+type Secret struct {
+	Value string `yaml:"value,omitempty"`
+	Env   string `yaml:"env,omitempty"`
+	Cmd   string `yaml:"cmd,omitempty"`
+}
+
+// secretCmds caches command output so password managers are only asked once
+var secretCmds sync.Map
+
+// ClearSecretCache forgets command output, so that commands are run again
+// after the config is reloaded
+func ClearSecretCache() {
+	secretCmds.Clear()
+}
+
+func (s *Secret) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Value = node.Value
+		return nil
+	}
+
+	type plain Secret
+	return node.Decode((*plain)(s))
+}
+
+func (s Secret) MarshalYAML() (any, error) {
+	if s.Env == "" && s.Cmd == "" {
+		return s.Value, nil
+	}
+
+	type plain Secret
+	return plain(s), nil
+}
+
+func (s Secret) IsZero() bool {
+	return s.Value == "" && s.Env == "" && s.Cmd == ""
+}
+
+// Redacted hides a secret given in the config file. Env and Cmd only name
+// where the secret comes from, so they are left as they are.
+func (s Secret) Redacted() Secret {
+	s.Value = redact(s.Value)
+	return s
+}
+
+// redactSecrets returns a copy of m with every secret redacted
+func redactSecrets(m map[string]Secret) map[string]Secret {
+	if m == nil {
+		return nil
+	}
+
+	r := make(map[string]Secret, len(m))
+	for k, v := range m {
+		r[k] = v.Redacted()
+	}
+	return r
+}
+
+// Validate checks that a secret command has something to run
+func (s Secret) Validate() error {
+	if s.Cmd != "" && len(strings.Fields(s.Cmd)) == 0 {
+		return errors.New("secret cmd is empty")
+	}
+	return nil
+}
+
+// Resolve returns the value of the secret
+func (s Secret) Resolve() (string, error) {
+	switch {
+	case s.Env != "":
+		v := os.Getenv(s.Env)
+		if v == "" {
+			return "", fmt.Errorf("config.Secret: %s: %w", s.Env, ErrSecretNotSet)
+		}
+		return v, nil
+
+	case s.Cmd != "":
+		if v, ok := secretCmds.Load(s.Cmd); ok {
+			return v.(string), nil
+		}
+
+		if err := s.Validate(); err != nil {
+			return "", fmt.Errorf("config.Secret: %w", err)
+		}
+
+		parts := strings.Fields(s.Cmd)
+		out, err := exec.Command(parts[0], parts[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("config.Secret: %s: %w", parts[0], err)
+		}
+
+		// only the first line, as with pass and most password managers
+		v := strings.TrimRight(strings.SplitN(string(out), "\n", 2)[0], "\r")
+		secretCmds.Store(s.Cmd, v)
+		return v, nil
+	}
+
+	return s.Value, nil
+}
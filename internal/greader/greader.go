@@ -0,0 +1,391 @@
+// Package greader is a client for the Google Reader API, as implemented by
+// FreshRSS, Miniflux, Inoreader, The Old Reader and others.
+package greader
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	StreamReadingList = "user/-/state/com.google/reading-list"
+	StateRead         = "user/-/state/com.google/read"
+	StateStarred      = "user/-/state/com.google/starred"
+)
+
+// editTagChunkSize is the number of item ids sent per edit-tag request
+const editTagChunkSize = 250
+
+var (
+	ErrUnauthorized = errors.New("greader: unauthorized")
+	errBadEditToken = errors.New("greader: bad edit token")
+)
+
+type Category struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type Subscription struct {
+	ID         string     `json:"id"`
+	Title      string     `json:"title"`
+	URL        string     `json:"url"`
+	HTMLURL    string     `json:"htmlUrl"`
+	Categories []Category `json:"categories"`
+}
+
+type Link struct {
+	Href string `json:"href"`
+}
+
+type Content struct {
+	Content string `json:"content"`
+}
+
+type Origin struct {
+	StreamID string `json:"streamId"`
+	Title    string `json:"title"`
+	HTMLURL  string `json:"htmlUrl"`
+}
+
+type Item struct {
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Published  int64    `json:"published"`
+	Canonical  []Link   `json:"canonical"`
+	Alternate  []Link   `json:"alternate"`
+	Summary    Content  `json:"summary"`
+	Content    Content  `json:"content"`
+	Author     string   `json:"author"`
+	Categories []string `json:"categories"`
+	Origin     Origin   `json:"origin"`
+}
+
+func (i Item) Link() string {
+	for _, l := range slices.Concat(i.Canonical, i.Alternate) {
+		if l.Href != "" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// HasState reports whether the item has a state such as StateRead. Servers
+// may return states for the actual user id rather than "-".
+func (i Item) HasState(state string) bool {
+	suffix := strings.TrimPrefix(state, "user/-")
+	for _, c := range i.Categories {
+		if c == state || (strings.HasPrefix(c, "user/") && strings.HasSuffix(c, suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+type Stream struct {
+	Items        []Item `json:"items"`
+	Continuation string `json:"continuation"`
+}
+
+type StreamOptions struct {
+	// Exclude is a state to leave out, e.g. StateRead for unread items only
+	Exclude string
+	// Count is the page size, the server default is used when 0
+	Count int
+}
+
+// Client is safe for concurrent use. The auth token from ClientLogin is
+// reused for all calls, and cached on disk when TokenFile is set so that it
+// survives between runs. It is only renewed when the server rejects it.
+type Client struct {
+	BaseURL    string
+	User       string
+	Password   string
+	HTTPClient *http.Client
+	TokenFile  string
+
+	mu        sync.Mutex
+	auth      string
+	editToken string
+}
+
+// New returns a client for baseURL, the root of the API such as
+// https://freshrss.example.com/api/greader.php
+func New(baseURL string, user string, password string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		User:       user,
+		Password:   password,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// DefaultTokenFile is a per server and user path in the user cache dir
+func DefaultTokenFile(baseURL string, user string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	sum := sha1.Sum([]byte(baseURL + "\x00" + user))
+	return filepath.Join(dir, "nom", "greader-"+hex.EncodeToString(sum[:8]))
+}
+
+// Login exchanges the user and password for an auth token
+func (c *Client) Login() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.login()
+}
+
+func (c *Client) login() error {
+	form := url.Values{"Email": {c.User}, "Passwd": {c.Password}}
+	resp, err := c.HTTPClient.PostForm(c.BaseURL+"/accounts/ClientLogin", form)
+	if err != nil {
+		return fmt.Errorf("greader.Login: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("greader.Login: %w", ErrUnauthorized)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("greader.Login: statusCode: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("greader.Login: %w", err)
+	}
+
+	// response is lines of key=value pairs, one of them Auth
+	for _, line := range strings.Split(string(body), "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "Auth="); ok {
+			c.auth = v
+			c.editToken = ""
+			c.saveToken()
+			return nil
+		}
+	}
+
+	return fmt.Errorf("greader.Login: no auth token in response")
+}
+
+func (c *Client) loadToken() {
+	if c.TokenFile == "" {
+		return
+	}
+
+	b, err := os.ReadFile(c.TokenFile)
+	if err == nil {
+		c.auth = strings.TrimSpace(string(b))
+	}
+}
+
+func (c *Client) saveToken() {
+	if c.TokenFile == "" {
+		return
+	}
+
+	// a failed write only means logging in again next time
+	if err := os.MkdirAll(filepath.Dir(c.TokenFile), 0700); err == nil {
+		_ = os.WriteFile(c.TokenFile, []byte(c.auth), 0600)
+	}
+}
+
+// do sends an authenticated request, logging in when there is no token yet
+// and once more if the server rejects the current one
+func (c *Client) do(method string, path string, form url.Values) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.auth == "" {
+		c.loadToken()
+	}
+
+	if c.auth == "" {
+		if err := c.login(); err != nil {
+			return nil, err
+		}
+	}
+
+	body, err := c.send(method, path, form)
+	if errors.Is(err, ErrUnauthorized) {
+		if err := c.login(); err != nil {
+			return nil, err
+		}
+		body, err = c.send(method, path, form)
+	}
+
+	return body, err
+}
+
+func (c *Client) send(method string, path string, form url.Values) ([]byte, error) {
+	var reqBody io.Reader
+	if form != nil {
+		if method == http.MethodPost && c.editToken != "" {
+			form.Set("T", c.editToken)
+		}
+		reqBody = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "GoogleLogin auth="+c.auth)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		// a stale edit token is reported with a header, the auth token is fine
+		if resp.Header.Get("X-Reader-Google-Bad-Token") == "true" {
+			c.editToken = ""
+			return nil, errBadEditToken
+		}
+		return nil, ErrUnauthorized
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("greader: %s %s: statusCode: %d", method, path, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func (c *Client) SubscriptionList() ([]Subscription, error) {
+	body, err := c.do(http.MethodGet, "/reader/api/0/subscription/list?output=json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("greader.SubscriptionList: %w", err)
+	}
+
+	var res struct {
+		Subscriptions []Subscription `json:"subscriptions"`
+	}
+	err = json.Unmarshal(body, &res)
+	if err != nil {
+		return nil, fmt.Errorf("greader.SubscriptionList: %w", err)
+	}
+
+	return res.Subscriptions, nil
+}
+
+// StreamContents returns every item in streamID, following continuations
+func (c *Client) StreamContents(streamID string, opts StreamOptions) ([]Item, error) {
+	var items []Item
+	continuation := ""
+
+	for {
+		q := url.Values{"output": {"json"}}
+		if opts.Exclude != "" {
+			q.Set("xt", opts.Exclude)
+		}
+		if opts.Count > 0 {
+			q.Set("n", strconv.Itoa(opts.Count))
+		}
+		if continuation != "" {
+			q.Set("c", continuation)
+		}
+
+		body, err := c.do(http.MethodGet, "/reader/api/0/stream/contents/"+streamID+"?"+q.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("greader.StreamContents: %w", err)
+		}
+
+		var s Stream
+		err = json.Unmarshal(body, &s)
+		if err != nil {
+			return nil, fmt.Errorf("greader.StreamContents: %w", err)
+		}
+
+		items = append(items, s.Items...)
+		if s.Continuation == "" || len(s.Items) == 0 {
+			return items, nil
+		}
+		continuation = s.Continuation
+	}
+}
+
+// EditTag adds and/or removes a tag or state, e.g. StateRead, on items
+func (c *Client) EditTag(itemIDs []string, add string, remove string) error {
+	for start := 0; start < len(itemIDs); start += editTagChunkSize {
+		chunk := itemIDs[start:min(start+editTagChunkSize, len(itemIDs))]
+
+		err := c.editTag(chunk, add, remove)
+		if err != nil {
+			return fmt.Errorf("greader.EditTag: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) editTag(itemIDs []string, add string, remove string) error {
+	if err := c.ensureEditToken(); err != nil {
+		return err
+	}
+
+	form := url.Values{"i": itemIDs}
+	if add != "" {
+		form.Set("a", add)
+	}
+	if remove != "" {
+		form.Set("r", remove)
+	}
+
+	_, err := c.do(http.MethodPost, "/reader/api/0/edit-tag", form)
+	if !errors.Is(err, errBadEditToken) {
+		return err
+	}
+
+	// the edit token may have expired, get a new one and try again
+	if err := c.ensureEditToken(); err != nil {
+		return err
+	}
+	_, err = c.do(http.MethodPost, "/reader/api/0/edit-tag", form)
+	return err
+}
+
+// ensureEditToken fetches the short lived token required for writes
+func (c *Client) ensureEditToken() error {
+	c.mu.Lock()
+	has := c.editToken != ""
+	c.mu.Unlock()
+
+	if has {
+		return nil
+	}
+
+	body, err := c.do(http.MethodGet, "/reader/api/0/token", nil)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.editToken = strings.TrimSpace(string(body))
+	c.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package greader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+type fakeServer struct {
+	mu       sync.Mutex
+	logins   int
+	token    string
+	edits    []string
+	editAuth []string
+}
+
+func (f *fakeServer) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /accounts/ClientLogin", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if r.FormValue("Email") != "user" || r.FormValue("Passwd") != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		f.logins++
+		f.token = fmt.Sprintf("user/%d", f.logins)
+		fmt.Fprintf(w, "SID=x\nLSID=x\nAuth=%s\n", f.token)
+	})
+
+	authed := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			f.mu.Lock()
+			ok := r.Header.Get("Authorization") == "GoogleLogin auth="+f.token
+			f.mu.Unlock()
+
+			if !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			h(w, r)
+		}
+	}
+
+	mux.HandleFunc("GET /reader/api/0/subscription/list", authed(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"subscriptions":[{"id":"feed/1","url":"http://example.com/feed","categories":[{"id":"user/-/label/News","label":"News"}]}]}`)
+	}))
+
+	mux.HandleFunc("GET /reader/api/0/token", authed(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "edit-token\n")
+	}))
+
+	mux.HandleFunc("GET /reader/api/0/stream/contents/", authed(func(w http.ResponseWriter, r *http.Request) {
+		s := Stream{Items: []Item{{ID: "1", Title: "one"}}, Continuation: "page2"}
+		if r.URL.Query().Get("c") == "page2" {
+			s = Stream{Items: []Item{{ID: "2", Title: "two", Categories: []string{"user/1234/state/com.google/read"}}}}
+		}
+		_ = json.NewEncoder(w).Encode(s)
+	}))
+
+	mux.HandleFunc("POST /reader/api/0/edit-tag", authed(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		if r.FormValue("T") != "edit-token" {
+			w.Header().Set("X-Reader-Google-Bad-Token", "true")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		f.edits = append(f.edits, strings.Join(r.Form["i"], ",")+" +"+r.FormValue("a")+" -"+r.FormValue("r"))
+		fmt.Fprint(w, "OK")
+	}))
+
+	return mux
+}
+
+func TestClientReusesToken(t *testing.T) {
+	f := &fakeServer{}
+	ts := httptest.NewServer(f.handler())
+	defer ts.Close()
+
+	c := New(ts.URL, "user", "pass")
+	c.TokenFile = filepath.Join(t.TempDir(), "token")
+
+	for range 3 {
+		subs, err := c.SubscriptionList()
+		test.HandleError(t, err)
+		test.Equal(t, "http://example.com/feed", subs[0].URL, "wrong feed url")
+	}
+	test.Equal(t, 1, f.logins, "expected a single login")
+
+	// a new client picks the token up from disk
+	c2 := New(ts.URL, "user", "pass")
+	c2.TokenFile = c.TokenFile
+	_, err := c2.SubscriptionList()
+	test.HandleError(t, err)
+	test.Equal(t, 1, f.logins, "expected token to be read from file")
+
+	// an expired token means logging in again, once
+	f.mu.Lock()
+	f.token = "expired"
+	f.mu.Unlock()
+
+	_, err = c.SubscriptionList()
+	test.HandleError(t, err)
+	test.Equal(t, 2, f.logins, "expected a second login after token expired")
+}
+
+func TestClientBadLogin(t *testing.T) {
+	f := &fakeServer{}
+	ts := httptest.NewServer(f.handler())
+	defer ts.Close()
+
+	c := New(ts.URL, "user", "wrong")
+	_, err := c.SubscriptionList()
+	if err == nil || !strings.Contains(err.Error(), ErrUnauthorized.Error()) {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+}
+
+func TestStreamContents(t *testing.T) {
+	f := &fakeServer{}
+	ts := httptest.NewServer(f.handler())
+	defer ts.Close()
+
+	c := New(ts.URL, "user", "pass")
+	items, err := c.StreamContents(StreamReadingList, StreamOptions{Count: 1})
+	test.HandleError(t, err)
+
+	test.Equal(t, 2, len(items), "expected continuation to be followed")
+	test.Equal(t, false, items[0].HasState(StateRead), "first item should be unread")
+	test.Equal(t, true, items[1].HasState(StateRead), "second item should be read")
+}
+
+func TestEditTag(t *testing.T) {
+	f := &fakeServer{}
+	ts := httptest.NewServer(f.handler())
+	defer ts.Close()
+
+	c := New(ts.URL, "user", "pass")
+	err := c.EditTag([]string{"1", "2"}, StateRead, "")
+	test.HandleError(t, err)
+
+	// a stale edit token is replaced without logging in again
+	c.mu.Lock()
+	c.editToken = "stale"
+	c.mu.Unlock()
+
+	err = c.EditTag([]string{"3"}, "", StateStarred)
+	test.HandleError(t, err)
+
+	test.Equal(t, 2, len(f.edits), "expected two edits")
+	test.Equal(t, "1,2 +"+StateRead+" -", f.edits[0], "wrong first edit")
+	test.Equal(t, "3 + -"+StateStarred, f.edits[1], "wrong second edit")
+	test.Equal(t, 1, f.logins, "expected a single login")
+}
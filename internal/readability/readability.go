@@ -0,0 +1,294 @@
+// Package readability finds the main content of a web page, in the style of
+// Arc90's Readability: paragraphs score their ancestors, the best scoring
+// element is taken as the article and related siblings are kept with it.
+package readability
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// ErrNoContent is returned when a page has nothing that looks like an article
+var ErrNoContent = errors.New("readability: no article content found")
+
+type Article struct {
+	Title string
+	// Content is the HTML of the article
+	Content string
+}
+
+var (
+	unlikely = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|gdpr|header|legends|menu|modal|newsletter|pager|pagination|popup|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|supplemental|tags|tool|widget|ad-break|agegate`)
+	maybe    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positive = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negative = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// removed are never part of an article
+const removed = "script, style, noscript, iframe, form, nav, aside, svg, button, input, select, textarea, link, meta, object, embed"
+
+// Extract returns the main content of the page in r. pageURL is used to make
+// links and images absolute, and may be nil.
+func Extract(r io.Reader, pageURL *url.URL) (Article, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return Article{}, fmt.Errorf("readability.Extract: %w", err)
+	}
+
+	article := Article{Title: pageTitle(doc)}
+
+	doc.Find(removed).Remove()
+	removeUnlikely(doc)
+
+	top, scores := topCandidate(doc)
+	if top == nil {
+		return article, ErrNoContent
+	}
+
+	content := doc.FindNodes(top.node).Parent().Children().FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return s.Get(0) == top.node || isRelatedSibling(s, top, scores)
+	})
+
+	wrapper := goquery.NewDocumentFromNode(&html.Node{Type: html.ElementNode, Data: "div"}).Selection
+	for _, n := range content.Nodes {
+		wrapper.AppendNodes(n)
+	}
+
+	clean(wrapper)
+	absolutise(wrapper, pageURL)
+
+	out, err := goquery.OuterHtml(wrapper)
+	if err != nil {
+		return article, fmt.Errorf("readability.Extract: %w", err)
+	}
+
+	if strings.TrimSpace(wrapper.Text()) == "" {
+		return article, ErrNoContent
+	}
+
+	article.Content = out
+	return article, nil
+}
+
+func pageTitle(doc *goquery.Document) string {
+	if t, ok := doc.Find(`meta[property="og:title"]`).Attr("content"); ok && strings.TrimSpace(t) != "" {
+		return strings.TrimSpace(t)
+	}
+
+	return strings.TrimSpace(doc.Find("title").First().Text())
+}
+
+func classAndID(s *goquery.Selection) string {
+	class, _ := s.Attr("class")
+	id, _ := s.Attr("id")
+	return class + " " + id
+}
+
+// removeUnlikely drops elements whose class or id says they're page furniture
+func removeUnlikely(doc *goquery.Document) {
+	doc.Find("*").Each(func(_ int, s *goquery.Selection) {
+		switch goquery.NodeName(s) {
+		case "html", "body", "article", "main":
+			return
+		}
+
+		ci := classAndID(s)
+		if unlikely.MatchString(ci) && !maybe.MatchString(ci) {
+			s.Remove()
+		}
+	})
+
+	doc.Find("header, footer").Each(func(_ int, s *goquery.Selection) {
+		// article headers hold the title and byline, page headers the site menu
+		if s.ParentsFiltered("article").Length() == 0 {
+			s.Remove()
+		}
+	})
+}
+
+type candidate struct {
+	node  *html.Node
+	score float64
+}
+
+// classWeight scores an element on how its class and id read
+func classWeight(s *goquery.Selection) float64 {
+	var w float64
+	ci := classAndID(s)
+	if negative.MatchString(ci) {
+		w -= 25
+	}
+	if positive.MatchString(ci) {
+		w += 25
+	}
+	return w
+}
+
+func initialScore(s *goquery.Selection) float64 {
+	score := classWeight(s)
+	switch goquery.NodeName(s) {
+	case "article":
+		score += 10
+	case "div", "main", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	return score
+}
+
+// linkDensity is the share of an element's text that is in links
+func linkDensity(s *goquery.Selection) float64 {
+	text := len(strings.TrimSpace(s.Text()))
+	if text == 0 {
+		return 0
+	}
+
+	var links int
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += len(strings.TrimSpace(a.Text()))
+	})
+
+	return float64(links) / float64(text)
+}
+
+func topCandidate(doc *goquery.Document) (*candidate, map[*html.Node]*candidate) {
+	scores := map[*html.Node]*candidate{}
+	var order []*candidate
+
+	score := func(s *goquery.Selection, points float64) {
+		if s.Length() == 0 || s.Get(0).Type != html.ElementNode {
+			return
+		}
+		n := s.Get(0)
+		c, ok := scores[n]
+		if !ok {
+			c = &candidate{node: n, score: initialScore(s)}
+			scores[n] = c
+			order = append(order, c)
+		}
+		c.score += points
+	}
+
+	doc.Find("p, pre, td, blockquote").Each(func(_ int, s *goquery.Selection) {
+		text := strings.TrimSpace(s.Text())
+		if len(text) < 25 {
+			return
+		}
+
+		points := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+
+		parent := s.Parent()
+		score(parent, points)
+		score(parent.Parent(), points/2)
+	})
+
+	var top *candidate
+	for _, c := range order {
+		c.score *= 1 - linkDensity(doc.FindNodes(c.node))
+		if top == nil || c.score > top.score {
+			top = c
+		}
+	}
+
+	if top == nil {
+		body := doc.Find("body")
+		if body.Length() == 0 {
+			return nil, scores
+		}
+		return &candidate{node: body.Get(0)}, scores
+	}
+
+	return top, scores
+}
+
+// isRelatedSibling keeps siblings of the top candidate that look like more of
+// the article, e.g. when paragraphs aren't wrapped in a single element
+func isRelatedSibling(s *goquery.Selection, top *candidate, scores map[*html.Node]*candidate) bool {
+	if c, ok := scores[s.Get(0)]; ok {
+		// siblings sharing the top candidate's class are likely more of it
+		var bonus float64
+		topClass, _ := goquery.NewDocumentFromNode(top.node).Attr("class")
+		if class, _ := s.Attr("class"); class != "" && class == topClass {
+			bonus = top.score * 0.2
+		}
+
+		if c.score+bonus >= math.Max(10, top.score*0.2) {
+			return true
+		}
+	}
+
+	if goquery.NodeName(s) == "p" {
+		text := strings.TrimSpace(s.Text())
+		density := linkDensity(s)
+		if len(text) > 80 && density < 0.25 {
+			return true
+		}
+		if len(text) > 0 && len(text) <= 80 && density == 0 && strings.ContainsAny(text, ".!?") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clean removes what's left of lists of links and empty elements
+func clean(s *goquery.Selection) {
+	s.Find("div, section, ul, ol, table").Each(func(_ int, el *goquery.Selection) {
+		text := len(strings.TrimSpace(el.Text()))
+		images := el.Find("img").Length()
+
+		if classWeight(el) < 0 && text < 500 {
+			el.Remove()
+			return
+		}
+
+		if linkDensity(el) > 0.5 && text < 500 && images == 0 {
+			el.Remove()
+		}
+	})
+
+	s.Find("p").Each(func(_ int, p *goquery.Selection) {
+		if strings.TrimSpace(p.Text()) == "" && p.Find("img").Length() == 0 {
+			p.Remove()
+		}
+	})
+
+	s.Find("*").RemoveAttr("style").RemoveAttr("class").RemoveAttr("id")
+}
+
+// absolutise resolves links and images against the page URL
+func absolutise(s *goquery.Selection, pageURL *url.URL) {
+	if pageURL == nil {
+		return
+	}
+
+	resolve := func(attr string) func(int, *goquery.Selection) {
+		return func(_ int, el *goquery.Selection) {
+			v, ok := el.Attr(attr)
+			if !ok {
+				return
+			}
+			ref, err := url.Parse(strings.TrimSpace(v))
+			if err != nil {
+				return
+			}
+			el.SetAttr(attr, pageURL.ResolveReference(ref).String())
+		}
+	}
+
+	s.Find("a[href]").Each(resolve("href"))
+	s.Find("img[src]").Each(resolve("src"))
+}
@@ -0,0 +1,50 @@
+package readability
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+const page = `<!doctype html>
+<html><head><title>Site | Full story</title><meta property="og:title" content="Full story"></head>
+<body>
+<header><nav><a href="/">Home</a> <a href="/about">About</a></nav></header>
+<div class="sidebar"><p>Subscribe to our newsletter, it's great, honestly, you'll love it.</p></div>
+<div id="main">
+  <article class="post">
+    <h1>Full story</h1>
+    <p>The first paragraph of the story, which goes on for a while, with commas, to look like prose.</p>
+    <p>A second paragraph follows, with <a href="/more">a relative link</a>, and more words, to be sure.</p>
+    <img src="/img/chart.png">
+    <p>The last paragraph wraps things up, again, with plenty of text so it scores well enough.</p>
+    <div class="share"><a href="https://twitter.com">Tweet</a> <a href="https://facebook.com">Share</a></div>
+  </article>
+  <div class="comments"><p>First! This comment is long enough to count as a paragraph, sadly, for scoring.</p></div>
+</div>
+<footer><p>Copyright the site, all rights reserved, since forever and always, probably.</p></footer>
+<script>track()</script>
+</body></html>`
+
+func TestExtract(t *testing.T) {
+	u, _ := url.Parse("https://example.com/stories/1")
+
+	a, err := Extract(strings.NewReader(page), u)
+	test.HandleError(t, err)
+
+	test.Equal(t, "Full story", a.Title, "expected og:title")
+	test.Equal(t, true, strings.Contains(a.Content, "The first paragraph"), "expected article text")
+	test.Equal(t, true, strings.Contains(a.Content, "wraps things up"), "expected all paragraphs")
+	test.Equal(t, true, strings.Contains(a.Content, `href="https://example.com/more"`), "expected links to be absolute")
+	test.Equal(t, true, strings.Contains(a.Content, `src="https://example.com/img/chart.png"`), "expected images to be absolute")
+
+	for _, furniture := range []string{"newsletter", "Home", "Tweet", "First!", "Copyright", "track()"} {
+		test.Equal(t, false, strings.Contains(a.Content, furniture), "expected page furniture to be dropped: "+furniture)
+	}
+
+	_, err = Extract(strings.NewReader(`<html><body><script>x()</script></body></html>`), nil)
+	test.Equal(t, true, errors.Is(err, ErrNoContent), "expected empty page to have no content")
+}
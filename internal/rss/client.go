@@ -0,0 +1,163 @@
+package rss
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+)
+
+// transports are shared between requests with the same connection settings,
+// so connections are reused across refreshes
+var (
+	transportsMu sync.Mutex
+	transports   = map[string]*http.Transport{}
+)
+
+// NewClient returns a client for the given options. A nil opts uses the
+// defaults.
+func NewClient(opts *config.HTTPOptions) (*http.Client, error) {
+	tr, err := transport(opts)
+	if err != nil {
+		return nil, fmt.Errorf("rss.NewClient: %w", err)
+	}
+
+	return &http.Client{
+		Transport:     tr,
+		Timeout:       opts.TimeoutDuration(),
+		CheckRedirect: dropHeadersOnRedirect(opts),
+	}, nil
+}
+
+// maxRedirects matches the limit of http.Client's default redirect policy
+const maxRedirects = 10
+
+// dropHeadersOnRedirect stops the configured headers, often API tokens,
+// following a redirect to another host. The client only drops Authorization
+// and Cookie itself.
+func dropHeadersOnRedirect(opts *config.HTTPOptions) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+
+		if opts == nil || strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+			return nil
+		}
+
+		for name := range opts.Headers {
+			req.Header.Del(name)
+		}
+		return nil
+	}
+}
+
+func transport(opts *config.HTTPOptions) (*http.Transport, error) {
+	if opts == nil {
+		opts = &config.HTTPOptions{}
+	}
+
+	key := strings.Join([]string{opts.Proxy, opts.MinTLSVersion, fmt.Sprint(opts.Insecure), opts.CACert}, "\x00")
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+
+	if tr, ok := transports[key]; ok {
+		return tr, nil
+	}
+
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.Proxy = http.ProxyFromEnvironment
+
+	if opts.Proxy != "" {
+		u, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("transport: invalid proxy: %w", err)
+		}
+		tr.Proxy = http.ProxyURL(u)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.Insecure}
+	if version, err := config.TLSVersion(opts.MinTLSVersion); err == nil {
+		tlsConfig.MinVersion = version
+	}
+
+	if opts.CACert != "" {
+		pem, err := os.ReadFile(opts.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("transport: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("transport: no certificates in %s", opts.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	tr.TLSClientConfig = tlsConfig
+	transports[key] = tr
+
+	return tr, nil
+}
+
+// NewRequest returns a GET request for u with the user agent, headers,
+// cookies and auth from opts
+func NewRequest(u string, opts *config.HTTPOptions, version string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("rss.NewRequest: %w", err)
+	}
+
+	req.Header.Set("User-Agent", fmt.Sprintf("nom/%s", version))
+	if opts == nil {
+		return req, nil
+	}
+
+	if opts.UserAgent != "" {
+		req.Header.Set("User-Agent", opts.UserAgent)
+	}
+
+	for name, secret := range opts.Headers {
+		v, err := secret.Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("rss.NewRequest: header %s: %w", name, err)
+		}
+		req.Header.Set(name, v)
+	}
+
+	for name, secret := range opts.Cookies {
+		v, err := secret.Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("rss.NewRequest: cookie %s: %w", name, err)
+		}
+		req.AddCookie(&http.Cookie{Name: name, Value: v})
+	}
+
+	if a := opts.Auth; a != nil {
+		if a.Username != "" {
+			password, err := a.Password.Resolve()
+			if err != nil {
+				return nil, fmt.Errorf("rss.NewRequest: password: %w", err)
+			}
+			req.SetBasicAuth(a.Username, password)
+		} else if !a.Token.IsZero() {
+			token, err := a.Token.Resolve()
+			if err != nil {
+				return nil, fmt.Errorf("rss.NewRequest: token: %w", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	return req, nil
+}
@@ -0,0 +1,184 @@
+package rss
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mmcdole/gofeed"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+)
+
+// ErrNoFeedsFound is returned by Discover when a page links to no feeds
+var ErrNoFeedsFound = errors.New("rss.Discover: no feeds found")
+
+// Candidate is a feed found by Discover, checked to parse
+type Candidate struct {
+	URL   string
+	Title string
+}
+
+// feedTypes are the link types advertising a feed
+var feedTypes = []string{
+	"application/rss+xml",
+	"application/atom+xml",
+	"application/feed+json",
+	"application/json",
+}
+
+// commonPaths are tried when a page doesn't advertise any feeds
+var commonPaths = []string{"/feed", "/rss", "/rss.xml", "/feed.xml", "/atom.xml", "/index.xml", "/feed.json"}
+
+// maxDiscoverSize stops huge pages from being read into memory
+const maxDiscoverSize = 5 << 20
+
+// Discover returns the feeds for a URL: the URL itself if it is a feed, or
+// the feeds an HTML page links to, falling back to common feed paths. Only
+// feeds that parse are returned. See NewSource for the URLs that aren't
+// fetched over HTTP.
+func Discover(pageURL string, httpOpts *config.HTTPOptions, version string) ([]Candidate, error) {
+	// local files and commands are taken as they are, only checked to parse
+	if !strings.HasPrefix(pageURL, "http://") && !strings.HasPrefix(pageURL, "https://") {
+		r, err := Fetch(config.Feed{URL: pageURL}, httpOpts, version, CacheHeaders{})
+		if err != nil {
+			return nil, fmt.Errorf("rss.Discover: %w", err)
+		}
+		return []Candidate{{URL: pageURL, Title: r.Channel.Title}}, nil
+	}
+
+	client, err := NewClient(httpOpts)
+	if err != nil {
+		return nil, fmt.Errorf("rss.Discover: %w", err)
+	}
+
+	body, finalURL, err := get(client, httpOpts, pageURL, version)
+	if err != nil {
+		return nil, fmt.Errorf("rss.Discover: %w", err)
+	}
+
+	if feed, err := gofeed.NewParser().Parse(bytes.NewReader(body)); err == nil {
+		return []Candidate{{URL: pageURL, Title: feed.Title}}, nil
+	}
+
+	links, err := feedLinks(body, finalURL)
+	if err != nil {
+		return nil, fmt.Errorf("rss.Discover: %w", err)
+	}
+
+	if len(links) == 0 {
+		for _, p := range commonPaths {
+			links = append(links, finalURL.ResolveReference(&url.URL{Path: p}).String())
+		}
+	}
+
+	var candidates []Candidate
+	for _, l := range links {
+		c, err := checkFeed(client, httpOpts, l, version)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrNoFeedsFound
+	}
+
+	return candidates, nil
+}
+
+func get(client *http.Client, opts *config.HTTPOptions, u string, version string) ([]byte, *url.URL, error) {
+	req, err := NewRequest(u, opts, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDiscoverSize))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return body, resp.Request.URL, nil
+}
+
+// feedLinks returns the feeds advertised with <link rel="alternate"> in an
+// HTML page, resolved against base
+func feedLinks(body []byte, base *url.URL) ([]string, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var links []string
+	seen := map[string]bool{}
+	doc.Find("link[rel]").Each(func(_ int, s *goquery.Selection) {
+		rel, _ := s.Attr("rel")
+		typ, _ := s.Attr("type")
+		href, _ := s.Attr("href")
+		if !hasWord(rel, "alternate") || !isFeedType(typ) || strings.TrimSpace(href) == "" {
+			return
+		}
+
+		ref, err := url.Parse(strings.TrimSpace(href))
+		if err != nil {
+			return
+		}
+
+		l := base.ResolveReference(ref).String()
+		if !seen[l] {
+			seen[l] = true
+			links = append(links, l)
+		}
+	})
+
+	return links, nil
+}
+
+func hasWord(s string, word string) bool {
+	for _, w := range strings.Fields(strings.ToLower(s)) {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+func isFeedType(t string) bool {
+	t = strings.ToLower(strings.TrimSpace(strings.Split(t, ";")[0]))
+	for _, ft := range feedTypes {
+		if t == ft {
+			return true
+		}
+	}
+	return false
+}
+
+func checkFeed(client *http.Client, opts *config.HTTPOptions, u string, version string) (Candidate, error) {
+	body, _, err := get(client, opts, u, version)
+	if err != nil {
+		return Candidate{}, err
+	}
+
+	feed, err := gofeed.NewParser().Parse(bytes.NewReader(body))
+	if err != nil {
+		return Candidate{}, err
+	}
+
+	return Candidate{URL: u, Title: feed.Title}, nil
+}
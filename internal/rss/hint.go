@@ -0,0 +1,80 @@
+package rss
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	gorss "github.com/mmcdole/gofeed/rss"
+)
+
+// ttlKey is where ttlTranslator keeps an RSS feed's <ttl>, which the
+// universal gofeed.Feed has no field for
+const ttlKey = "nom:ttl"
+
+// ttlTranslator is the default RSS translator, keeping <ttl> as well
+type ttlTranslator struct {
+	gofeed.DefaultRSSTranslator
+}
+
+func (t *ttlTranslator) Translate(feed interface{}) (*gofeed.Feed, error) {
+	f, err := t.DefaultRSSTranslator.Translate(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	if rf, ok := feed.(*gorss.Feed); ok && rf.TTL != "" {
+		if f.Custom == nil {
+			f.Custom = map[string]string{}
+		}
+		f.Custom[ttlKey] = rf.TTL
+	}
+
+	return f, nil
+}
+
+func newParser() *gofeed.Parser {
+	p := gofeed.NewParser()
+	p.RSSTranslator = &ttlTranslator{}
+	return p
+}
+
+var updatePeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// updateHint returns how often the publisher says the feed should be
+// fetched, from <ttl> in minutes or the syndication module's
+// sy:updatePeriod and sy:updateFrequency. It's 0 if the feed gives neither.
+func updateHint(feed *gofeed.Feed) time.Duration {
+	if mins, err := strconv.Atoi(strings.TrimSpace(feed.Custom[ttlKey])); err == nil && mins > 0 {
+		return time.Duration(mins) * time.Minute
+	}
+
+	sy, ok := feed.Extensions["sy"]
+	if !ok {
+		return 0
+	}
+
+	var period time.Duration
+	if p := sy["updatePeriod"]; len(p) > 0 {
+		period = updatePeriods[strings.ToLower(strings.TrimSpace(p[0].Value))]
+	}
+	if period == 0 {
+		return 0
+	}
+
+	// the period is divided between this many updates
+	if f := sy["updateFrequency"]; len(f) > 0 {
+		if n, err := strconv.Atoi(strings.TrimSpace(f[0].Value)); err == nil && n > 1 {
+			period /= time.Duration(n)
+		}
+	}
+
+	return period
+}
@@ -1,12 +1,14 @@
 package rss
 
 import (
-	"crypto/tls"
+	"errors"
 	"fmt"
-	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
 
 	"github.com/guyfedwards/nom/v2/internal/config"
 )
@@ -21,6 +23,19 @@ type Item struct {
 	Content     string    `xml:"encoded"`
 	PubDate     time.Time `xml:"pubDate"`
 	FeedName    string
+	Enclosures  []Enclosure
+	// Image is a thumbnail for the item, e.g. from iTunes or Media RSS
+	Image string
+}
+
+// Enclosure is a media file attached to an item, e.g. a podcast episode
+type Enclosure struct {
+	URL  string
+	Type string
+	// Length is the size in bytes, if the feed gives one
+	Length int64
+	// Duration is as given by the feed, usually seconds or HH:MM:SS
+	Duration string
 }
 
 type Channel struct {
@@ -31,38 +46,39 @@ type Channel struct {
 }
 
 type RSS struct {
-	Channel Channel `xml:"channel"`
+	Channel Channel      `xml:"channel"`
+	Cache   CacheHeaders `xml:"-"`
+	// StatusCode is the HTTP status of the response, also set when Fetch
+	// returns an error after a response was received
+	StatusCode int `xml:"-"`
+	// RetryAfter is how long the server asked to wait before trying again,
+	// from the Retry-After header of a 429 or 503
+	RetryAfter time.Duration `xml:"-"`
+	// UpdateHint is how often the feed asks to be fetched, from <ttl> or
+	// sy:updatePeriod, or 0 if it doesn't say
+	UpdateHint time.Duration `xml:"-"`
 }
 
-func Fetch(f config.Feed, httpOpts *config.HTTPOptions, version string) (RSS, error) {
-	fp := gofeed.NewParser()
-
-	tr := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-	}
-
-	if httpOpts != nil {
-		if version, err := config.TLSVersion(httpOpts.MinTLSVersion); err == nil {
-			tr.TLSClientConfig = &tls.Config{
-				MinVersion: version,
-			}
-		}
-	}
-
-	fp.Client = &http.Client{
-		Transport: tr,
-	}
+// CacheHeaders are the validators a server sent for a feed. They are sent
+// back on the next fetch so that unchanged feeds are answered with a 304.
+type CacheHeaders struct {
+	ETag         string
+	LastModified string
+}
 
-	fp.UserAgent = fmt.Sprintf("nom/%s", version)
+// ErrNotModified is returned by Fetch when the server reports that the feed
+// has not changed since the validators in CacheHeaders were issued.
+var ErrNotModified = errors.New("rss.Fetch: not modified")
 
-	feed, err := fp.ParseURL(f.URL)
+// Fetch fetches and parses a feed from wherever its URL points. httpOpts are
+// the global options, which the feed's own override.
+func Fetch(f config.Feed, httpOpts *config.HTTPOptions, version string, cache CacheHeaders) (RSS, error) {
+	src, err := NewSource(f, httpOpts, version)
 	if err != nil {
 		return RSS{}, fmt.Errorf("rss.Fetch: %w", err)
 	}
 
-	rss := feedToRSS(f, feed)
-
-	return rss, nil
+	return src.Fetch(cache)
 }
 
 func feedToRSS(f config.Feed, feed *gofeed.Feed) RSS {
@@ -100,6 +116,8 @@ func feedToRSS(f config.Feed, feed *gofeed.Feed) RSS {
 		}
 
 		ni.FeedName = f.Name
+		ni.Enclosures = itemEnclosures(it)
+		ni.Image = itemImage(it)
 
 		items = append(items, ni)
 	}
@@ -111,6 +129,82 @@ func feedToRSS(f config.Feed, feed *gofeed.Feed) RSS {
 		Description: feed.Description,
 		Items:       items,
 	}
+	rss.UpdateHint = updateHint(feed)
 
 	return rss
 }
+
+func itemEnclosures(it *gofeed.Item) []Enclosure {
+	var duration string
+	if it.ITunesExt != nil {
+		duration = it.ITunesExt.Duration
+	}
+
+	var encs []Enclosure
+	seen := map[string]bool{}
+	add := func(e Enclosure) {
+		if e.URL == "" || seen[e.URL] {
+			return
+		}
+		seen[e.URL] = true
+		encs = append(encs, e)
+	}
+
+	for _, e := range it.Enclosures {
+		if e == nil {
+			continue
+		}
+		length, _ := strconv.ParseInt(strings.TrimSpace(e.Length), 10, 64)
+		add(Enclosure{URL: e.URL, Type: e.Type, Length: length, Duration: duration})
+	}
+
+	// Media RSS content, either directly on the item or in a media:group as
+	// YouTube does. Images are thumbnails rather than something to play.
+	for _, m := range mediaElements(it, "content") {
+		if medium := m.Attrs["medium"]; medium == "image" || strings.HasPrefix(m.Attrs["type"], "image/") {
+			continue
+		}
+		length, _ := strconv.ParseInt(m.Attrs["fileSize"], 10, 64)
+		d := duration
+		if m.Attrs["duration"] != "" {
+			d = m.Attrs["duration"]
+		}
+		add(Enclosure{URL: m.Attrs["url"], Type: m.Attrs["type"], Length: length, Duration: d})
+	}
+
+	return encs
+}
+
+func itemImage(it *gofeed.Item) string {
+	if it.ITunesExt != nil && it.ITunesExt.Image != "" {
+		return it.ITunesExt.Image
+	}
+
+	for _, m := range mediaElements(it, "thumbnail") {
+		if m.Attrs["url"] != "" {
+			return m.Attrs["url"]
+		}
+	}
+
+	if it.Image != nil {
+		return it.Image.URL
+	}
+
+	return ""
+}
+
+// mediaElements returns the Media RSS elements with the given name, from the
+// item and any media:group in it
+func mediaElements(it *gofeed.Item, name string) []ext.Extension {
+	media, ok := it.Extensions["media"]
+	if !ok {
+		return nil
+	}
+
+	elems := append([]ext.Extension{}, media[name]...)
+	for _, g := range media["group"] {
+		elems = append(elems, g.Children[name]...)
+	}
+
+	return elems
+}
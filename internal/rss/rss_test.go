@@ -2,8 +2,12 @@ package rss
 
 import (
 	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/mmcdole/gofeed"
 
@@ -65,3 +69,181 @@ func TestFeedPubDateBadParse(t *testing.T) {
 	r := feedToRSS(config.Feed{}, fd)
 	test.Equal(t, "0001-01-01 00:00:00 +0000 UTC", r.Channel.Items[0].PubDate.String(), "dates don't match")
 }
+
+func TestFetchConditional(t *testing.T) {
+	fixture, err := os.ReadFile(dropboxFixture)
+	test.HandleError(t, err)
+
+	const etag = `"v1"`
+	const lastModified = "Wed, 19 Oct 2022 06:30:00 GMT"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write(fixture)
+	}))
+	defer srv.Close()
+
+	f := config.Feed{URL: srv.URL}
+
+	r, err := Fetch(f, nil, "test", CacheHeaders{})
+	test.HandleError(t, err)
+	test.Equal(t, 10, len(r.Channel.Items), "missing items")
+	test.Equal(t, etag, r.Cache.ETag, "bad etag")
+	test.Equal(t, lastModified, r.Cache.LastModified, "bad last-modified")
+
+	_, err = Fetch(f, nil, "test", r.Cache)
+	test.Equal(t, true, errors.Is(err, ErrNotModified), "expected not modified")
+}
+
+const podcastFeed = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:media="http://search.yahoo.com/mrss/">
+<channel><title>pod</title>
+<item>
+  <title>Episode 1</title>
+  <link>https://example.com/1</link>
+  <enclosure url="https://example.com/1.mp3" type="audio/mpeg" length="1234"/>
+  <itunes:duration>01:02:03</itunes:duration>
+  <itunes:image href="https://example.com/1.jpg"/>
+</item>
+<item>
+  <title>Video</title>
+  <link>https://example.com/2</link>
+  <media:group>
+    <media:content url="https://example.com/2.mp4" type="video/mp4" duration="90"/>
+    <media:thumbnail url="https://example.com/2.jpg"/>
+  </media:group>
+</item>
+</channel></rss>`
+
+func TestFeedToRssEnclosures(t *testing.T) {
+	fd, err := gofeed.NewParser().ParseString(podcastFeed)
+	test.HandleError(t, err)
+
+	r := feedToRSS(config.Feed{}, fd)
+
+	ep := r.Channel.Items[0]
+	test.Equal(t, 1, len(ep.Enclosures), "expected one enclosure")
+	test.Equal(t, Enclosure{URL: "https://example.com/1.mp3", Type: "audio/mpeg", Length: 1234, Duration: "01:02:03"}, ep.Enclosures[0], "bad enclosure")
+	test.Equal(t, "https://example.com/1.jpg", ep.Image, "bad itunes image")
+
+	video := r.Channel.Items[1]
+	test.Equal(t, 1, len(video.Enclosures), "expected media content as an enclosure")
+	test.Equal(t, Enclosure{URL: "https://example.com/2.mp4", Type: "video/mp4", Duration: "90"}, video.Enclosures[0], "bad media enclosure")
+	test.Equal(t, "https://example.com/2.jpg", video.Image, "bad media thumbnail")
+}
+
+func TestUpdateHint(t *testing.T) {
+	cases := map[string]time.Duration{
+		`<rss version="2.0"><channel><title>t</title><ttl>90</ttl></channel></rss>`: 90 * time.Minute,
+		`<rss version="2.0" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/"><channel><title>t</title>
+<sy:updatePeriod>daily</sy:updatePeriod><sy:updateFrequency>2</sy:updateFrequency></channel></rss>`: 12 * time.Hour,
+		`<feed xmlns="http://www.w3.org/2005/Atom" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/"><title>t</title>
+<sy:updatePeriod>weekly</sy:updatePeriod></feed>`: 7 * 24 * time.Hour,
+		`<rss version="2.0"><channel><title>t</title></channel></rss>`: 0,
+	}
+
+	for doc, want := range cases {
+		fd, err := newParser().ParseString(doc)
+		test.HandleError(t, err)
+		test.Equal(t, want, feedToRSS(config.Feed{}, fd).UpdateHint, "wrong hint for "+doc)
+	}
+}
+
+func TestFetchFeedHTTPOptions(t *testing.T) {
+	t.Setenv("NOM_TEST_TOKEN", "s3cret")
+
+	var got *http.Request
+	// acts as a proxy, so the feed's host doesn't need to resolve
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>internal</title></channel></rss>`))
+	}))
+	defer proxy.Close()
+
+	feed := config.Feed{
+		URL: "http://feeds.internal/news.xml",
+		HTTP: &config.HTTPOptions{
+			Proxy:     proxy.URL,
+			UserAgent: "internal-reader",
+			Headers:   map[string]config.Secret{"X-Team": {Value: "news"}},
+			Cookies:   map[string]config.Secret{"session": {Env: "NOM_TEST_TOKEN"}},
+			Auth:      &config.HTTPAuth{Token: config.Secret{Env: "NOM_TEST_TOKEN"}},
+		},
+	}
+
+	r, err := Fetch(feed, &config.HTTPOptions{MinTLSVersion: "TLS 1.2"}, "test", CacheHeaders{})
+	test.HandleError(t, err)
+	test.Equal(t, "internal", r.Channel.Title, "expected feed through the proxy")
+	test.Equal(t, "feeds.internal", got.Host, "expected request for the feed host")
+	test.Equal(t, "internal-reader", got.UserAgent(), "expected feed user agent")
+	test.Equal(t, "news", got.Header.Get("X-Team"), "expected feed header")
+	test.Equal(t, "Bearer s3cret", got.Header.Get("Authorization"), "expected bearer token from env")
+
+	cookie, err := got.Cookie("session")
+	test.HandleError(t, err)
+	test.Equal(t, "s3cret", cookie.Value, "expected cookie from env")
+}
+
+const jsonFeed = `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "builds",
+  "items": [{"id": "1", "url": "https://ci.internal/builds/1", "title": "Build 1 failed", "content_html": "<p>tests failed</p>"}]
+}`
+
+func TestSources(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/builds.json"
+	test.HandleError(t, os.WriteFile(path, []byte(jsonFeed), 0644))
+
+	src, err := NewSource(config.Feed{URL: "file://" + path}, nil, "test")
+	test.HandleError(t, err)
+
+	r, err := src.Fetch(CacheHeaders{})
+	test.HandleError(t, err)
+	test.Equal(t, "builds", r.Channel.Title, "expected json feed from file")
+	test.Equal(t, "Build 1 failed", r.Channel.Items[0].Title, "bad json feed item")
+
+	_, err = src.Fetch(r.Cache)
+	test.Equal(t, true, errors.Is(err, ErrNotModified), "expected unchanged file not to be parsed again")
+
+	r, err = Fetch(config.Feed{URL: "exec:cat " + path, Name: "ci"}, nil, "test", CacheHeaders{})
+	test.HandleError(t, err)
+	test.Equal(t, "Build 1 failed", r.Channel.Items[0].Title, "expected feed from command output")
+	test.Equal(t, "ci", r.Channel.Items[0].FeedName, "bad feed name")
+
+	_, err = Fetch(config.Feed{URL: "exec:false"}, nil, "test", CacheHeaders{})
+	test.Equal(t, true, err != nil, "expected failing command to fail")
+
+	_, err = NewSource(config.Feed{URL: "gopher://example.com"}, nil, "test")
+	test.Equal(t, true, errors.Is(err, ErrUnsupportedSource), "expected unknown scheme to fail")
+}
+
+func TestRedirectDropsHeaders(t *testing.T) {
+	var got http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.Write([]byte(`<?xml version="1.0"?><rss version="2.0"><channel><title>moved</title></channel></rss>`))
+	}))
+	defer other.Close()
+
+	feedHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/feed.xml", http.StatusFound)
+	}))
+	defer feedHost.Close()
+
+	feed := config.Feed{
+		URL:  feedHost.URL + "/feed.xml",
+		HTTP: &config.HTTPOptions{Headers: map[string]config.Secret{"X-Api-Key": {Value: "s3cret"}}},
+	}
+
+	r, err := Fetch(feed, nil, "test", CacheHeaders{})
+	test.HandleError(t, err)
+	test.Equal(t, "moved", r.Channel.Title, "expected redirect to be followed")
+	test.Equal(t, "", got.Get("X-Api-Key"), "configured headers shouldn't follow a redirect to another host")
+}
@@ -0,0 +1,221 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+
+	"github.com/guyfedwards/nom/v2/internal/config"
+)
+
+const (
+	fileScheme = "file://"
+	execPrefix = "exec:"
+)
+
+// ErrUnsupportedSource is returned for feed URLs no source can fetch
+var ErrUnsupportedSource = errors.New("unsupported feed source")
+
+// FeedSource fetches a feed and parses it. The validators in cache are used
+// to return ErrNotModified when the feed hasn't changed since the last fetch,
+// where the source supports it.
+type FeedSource interface {
+	Fetch(cache CacheHeaders) (RSS, error)
+}
+
+// NewSource returns the source for a feed URL:
+//
+//	https://example.com/feed   fetched over HTTP
+//	file:///path/to/feed.xml   read from a local file
+//	exec:/path/to/script args  the output of a command
+//
+// Any format gofeed understands works with each, i.e. RSS, Atom and JSON Feed.
+func NewSource(f config.Feed, httpOpts *config.HTTPOptions, version string) (FeedSource, error) {
+	switch {
+	case strings.HasPrefix(f.URL, "http://"), strings.HasPrefix(f.URL, "https://"):
+		return HTTPSource{Feed: f, Options: httpOpts.Merge(f.HTTP), Version: version}, nil
+
+	case strings.HasPrefix(f.URL, fileScheme):
+		u, err := url.Parse(f.URL)
+		if err != nil {
+			return nil, fmt.Errorf("rss.NewSource: %w", err)
+		}
+		path := u.Path
+		// file://feeds/x.xml is taken as relative rather than a host
+		if u.Host != "" && u.Host != "localhost" {
+			path = filepath.Join(u.Host, u.Path)
+		}
+		return FileSource{Feed: f, Path: path}, nil
+
+	case strings.HasPrefix(f.URL, execPrefix):
+		args := strings.Fields(strings.TrimPrefix(f.URL, execPrefix))
+		if len(args) == 0 {
+			return nil, fmt.Errorf("rss.NewSource: %s: no command given", f.URL)
+		}
+		return ExecSource{Feed: f, Args: args, Timeout: httpOpts.Merge(f.HTTP).TimeoutDuration()}, nil
+	}
+
+	return nil, fmt.Errorf("rss.NewSource: %s: %w", f.URL, ErrUnsupportedSource)
+}
+
+func parse(f config.Feed, r io.Reader) (RSS, error) {
+	feed, err := newParser().Parse(r)
+	if err != nil {
+		return RSS{}, err
+	}
+
+	return feedToRSS(f, feed), nil
+}
+
+// HTTPSource fetches a feed over HTTP, with conditional requests
+type HTTPSource struct {
+	Feed    config.Feed
+	Options *config.HTTPOptions
+	Version string
+}
+
+func (s HTTPSource) Fetch(cache CacheHeaders) (RSS, error) {
+	client, err := NewClient(s.Options)
+	if err != nil {
+		return RSS{}, fmt.Errorf("rss.Fetch: %w", err)
+	}
+
+	req, err := NewRequest(s.Feed.URL, s.Options, s.Version)
+	if err != nil {
+		return RSS{}, fmt.Errorf("rss.Fetch: %w", err)
+	}
+
+	if cache.ETag != "" {
+		req.Header.Set("If-None-Match", cache.ETag)
+	}
+	if cache.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cache.LastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return RSS{}, fmt.Errorf("rss.Fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return RSS{Cache: cache, StatusCode: resp.StatusCode}, ErrNotModified
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		r := RSS{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now())}
+		return r, fmt.Errorf("rss.Fetch: %w", gofeed.HTTPError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+		})
+	}
+
+	rss, err := parse(s.Feed, resp.Body)
+	if err != nil {
+		return RSS{StatusCode: resp.StatusCode}, fmt.Errorf("rss.Fetch: %w", err)
+	}
+
+	rss.StatusCode = resp.StatusCode
+	rss.Cache = CacheHeaders{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	return rss, nil
+}
+
+// FileSource reads a feed from a local file, skipping it while the
+// modification time is unchanged
+type FileSource struct {
+	Feed config.Feed
+	Path string
+}
+
+func (s FileSource) Fetch(cache CacheHeaders) (RSS, error) {
+	fi, err := os.Stat(s.Path)
+	if err != nil {
+		return RSS{}, fmt.Errorf("rss.Fetch: %w", err)
+	}
+
+	modified := fi.ModTime().UTC().Format(http.TimeFormat)
+	if cache.LastModified == modified {
+		return RSS{Cache: cache}, ErrNotModified
+	}
+
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return RSS{}, fmt.Errorf("rss.Fetch: %w", err)
+	}
+	defer f.Close()
+
+	rss, err := parse(s.Feed, f)
+	if err != nil {
+		return RSS{}, fmt.Errorf("rss.Fetch: %s: %w", s.Path, err)
+	}
+
+	rss.Cache = CacheHeaders{LastModified: modified}
+	return rss, nil
+}
+
+// ExecSource runs a command and parses what it prints. The command is run
+// directly rather than through a shell.
+type ExecSource struct {
+	Feed    config.Feed
+	Args    []string
+	Timeout time.Duration
+}
+
+func (s ExecSource) Fetch(_ CacheHeaders) (RSS, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.Args[0], s.Args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return RSS{}, fmt.Errorf("rss.Fetch: %s: %w: %s", s.Args[0], err, msg)
+		}
+		return RSS{}, fmt.Errorf("rss.Fetch: %s: %w", s.Args[0], err)
+	}
+
+	rss, err := parse(s.Feed, &stdout)
+	if err != nil {
+		return RSS{}, fmt.Errorf("rss.Fetch: %s: %w", s.Args[0], err)
+	}
+
+	return rss, nil
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as a date
+func retryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"math/bits"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// duplicateWindow is how far back new items are compared by content.
+	// Links and GUIDs are matched regardless of age.
+	duplicateWindow = 14 * 24 * time.Hour
+	// simhashDistance is the number of differing bits under which two items
+	// are considered the same article
+	simhashDistance = 3
+	// minSimhashWords is the number of words needed for a useful hash, below
+	// which items are only matched on links and GUIDs
+	minSimhashWords = 20
+	// minGUIDLength skips GUIDs too short to be unique across feeds, such as
+	// "1" or "42"
+	minGUIDLength = 10
+)
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// NormaliseLink returns a link without its scheme, fragment, trailing slash
+// and tracking parameters, so copies of an article posted with different
+// links compare equal
+func NormaliseLink(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(strings.TrimSpace(link), "/")
+	}
+
+	q := u.Query()
+	for k := range q {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "utm_") || lk == "fbclid" || lk == "gclid" {
+			q.Del(k)
+		}
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	norm := host + strings.TrimRight(u.EscapedPath(), "/")
+	if len(q) > 0 {
+		norm += "?" + q.Encode()
+	}
+
+	return norm
+}
+
+// Simhash hashes the words of title and content so that similar text gives
+// hashes that differ in few bits. ok is false when there are too few words
+// for the hash to mean anything.
+func Simhash(title string, content string) (hash uint64, ok bool) {
+	text := html.UnescapeString(tagPattern.ReplaceAllString(title+" "+content, " "))
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	if len(words) < minSimhashWords {
+		return 0, false
+	}
+
+	var weights [64]int
+	for i := 0; i < len(words)-1; i++ {
+		h := fnv.New64a()
+		h.Write([]byte(words[i] + " " + words[i+1]))
+		sum := h.Sum64()
+
+		for b := range 64 {
+			if sum&(1<<b) != 0 {
+				weights[b]++
+			} else {
+				weights[b]--
+			}
+		}
+	}
+
+	for b, w := range weights {
+		if w > 0 {
+			hash |= 1 << b
+		}
+	}
+
+	return hash, true
+}
+
+// duplicateKeys are the values an item is matched against other feeds with
+type duplicateKeys struct {
+	normlink string
+	guid     string
+	simhash  sql.NullInt64
+}
+
+func newDuplicateKeys(item *Item) duplicateKeys {
+	k := duplicateKeys{normlink: NormaliseLink(item.Link)}
+
+	if len(item.GUID) >= minGUIDLength {
+		k.guid = item.GUID
+	}
+
+	if hash, ok := Simhash(item.Title, item.Content); ok {
+		k.simhash = sql.NullInt64{Int64: int64(hash), Valid: true}
+	}
+
+	return k
+}
+
+// findDuplicate returns the ID of an item from another feed that is the same
+// article, or 0. Only original items are matched, so every copy points at the
+// first one seen.
+func findDuplicate(db statementPreparer, item *Item, k duplicateKeys) (int, error) {
+	stmt, err := db.Prepare(`
+		select id from items
+		where feedurl != ?1 and duplicateof is null
+		and ((?2 != '' and normlink = ?2) or (?3 != '' and guid = ?3))
+		order by id limit 1;
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("findDuplicate: %w", err)
+	}
+
+	var id int
+	err = stmt.QueryRow(item.FeedURL, k.normlink, k.guid).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("findDuplicate: %w", err)
+	}
+
+	if !k.simhash.Valid {
+		return 0, nil
+	}
+
+	stmt, err = db.Prepare(`
+		select id, simhash from items
+		where feedurl != ? and duplicateof is null and simhash is not null and createdat > ?
+		order by id;
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("findDuplicate: %w", err)
+	}
+
+	rows, err := stmt.Query(item.FeedURL, time.Now().Add(-duplicateWindow))
+	if err != nil {
+		return 0, fmt.Errorf("findDuplicate: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash int64
+		err := rows.Scan(&id, &hash)
+		if err != nil {
+			return 0, fmt.Errorf("findDuplicate: %w", err)
+		}
+
+		if bits.OnesCount64(uint64(hash^k.simhash.Int64)) <= simhashDistance {
+			return id, nil
+		}
+	}
+
+	return 0, rows.Err()
+}
+
+// backfillDuplicateKeys sets the keys of items stored before duplicates were
+// detected, so new copies of them are found. Existing items aren't linked to
+// each other.
+func backfillDuplicateKeys(db *sql.DB) error {
+	rows, err := db.Query(`select id, link, guid, title, content from items where normlink is null;`)
+	if err != nil {
+		return fmt.Errorf("backfillDuplicateKeys: %w", err)
+	}
+
+	var items []Item
+	for rows.Next() {
+		var item Item
+		var link, guid sql.NullString
+		err := rows.Scan(&item.ID, &link, &guid, &item.Title, &item.Content)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("backfillDuplicateKeys: %w", err)
+		}
+		item.Link = link.String
+		item.GUID = guid.String
+		items = append(items, item)
+	}
+	rows.Close()
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("backfillDuplicateKeys: %w", err)
+	}
+
+	for _, item := range items {
+		k := newDuplicateKeys(&item)
+		_, err := tx.Exec(`update items set normlink = ?, simhash = ? where id = ?;`, k.normlink, k.simhash, item.ID)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("backfillDuplicateKeys: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
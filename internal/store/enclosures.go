@@ -0,0 +1,68 @@
+package store
+
+import (
+	"fmt"
+)
+
+// Enclosure is a media file attached to an item, e.g. a podcast episode
+type Enclosure struct {
+	ID     int
+	ItemID int
+	URL    string
+	Type   string
+	// Length is the size in bytes, 0 when unknown
+	Length   int64
+	Duration string
+}
+
+// saveEnclosures replaces the enclosures stored for an item. Items without
+// enclosures keep what they had, as not every source lists them.
+func saveEnclosures(db statementPreparer, itemID int, encs []Enclosure) error {
+	if len(encs) == 0 {
+		return nil
+	}
+
+	stmt, err := db.Prepare(`delete from enclosures where itemid = ?;`)
+	if err != nil {
+		return fmt.Errorf("saveEnclosures: %w", err)
+	}
+
+	_, err = stmt.Exec(itemID)
+	if err != nil {
+		return fmt.Errorf("saveEnclosures: %w", err)
+	}
+
+	stmt, err = db.Prepare(`insert into enclosures (itemid, url, type, length, duration) values (?, ?, ?, ?, ?);`)
+	if err != nil {
+		return fmt.Errorf("saveEnclosures: %w", err)
+	}
+
+	for _, e := range encs {
+		_, err = stmt.Exec(itemID, e.URL, e.Type, e.Length, e.Duration)
+		if err != nil {
+			return fmt.Errorf("saveEnclosures: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (sls SQLiteStore) getEnclosures(itemID int) ([]Enclosure, error) {
+	rows, err := sls.db.Query(`select id, itemid, url, type, length, duration from enclosures where itemid = ? order by id;`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("getEnclosures: %w", err)
+	}
+	defer rows.Close()
+
+	var encs []Enclosure
+	for rows.Next() {
+		var e Enclosure
+		err := rows.Scan(&e.ID, &e.ItemID, &e.URL, &e.Type, &e.Length, &e.Duration)
+		if err != nil {
+			return encs, fmt.Errorf("getEnclosures: %w", err)
+		}
+		encs = append(encs, e)
+	}
+
+	return encs, rows.Err()
+}
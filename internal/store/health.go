@@ -0,0 +1,100 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// fetchLogLimit is the number of fetch attempts kept per feed
+const fetchLogLimit = 100
+
+// FetchLog is one attempt at fetching a feed
+type FetchLog struct {
+	FeedURL   string
+	FetchedAt time.Time
+	// Status is the HTTP status, 0 if no response was received
+	Status   int
+	Duration time.Duration
+	// Error is empty if the fetch succeeded
+	Error    string
+	NewItems int
+}
+
+// RecordFetch adds a fetch attempt to the log and updates the feed's last
+// fetch status and failure count
+func (sls *SQLiteStore) RecordFetch(entry FetchLog) error {
+	db := sls.conn()
+	at := entry.FetchedAt.UTC()
+
+	var errStr sql.NullString
+	if entry.Error != "" {
+		errStr = sql.NullString{String: entry.Error, Valid: true}
+	}
+
+	stmt, err := db.Prepare(`insert into fetch_log (feedurl, fetchedat, status, durationms, error, newitems) values (?, ?, ?, ?, ?, ?);`)
+	if err != nil {
+		return fmt.Errorf("[store.go] RecordFetch: %w", err)
+	}
+
+	_, err = stmt.Exec(entry.FeedURL, at, entry.Status, entry.Duration.Milliseconds(), errStr, entry.NewItems)
+	if err != nil {
+		return fmt.Errorf("[store.go] RecordFetch: %w", err)
+	}
+
+	stmt, err = db.Prepare(`insert into feeds (feedurl, lastfetchedat, lasterror, laststatus, lastsuccessat, failures)
+		values (?1, ?2, ?3, ?4, case when ?3 is null then ?2 end, case when ?3 is null then 0 else 1 end)
+		on conflict(feedurl) do update set
+			lastfetchedat = excluded.lastfetchedat,
+			lasterror = excluded.lasterror,
+			laststatus = excluded.laststatus,
+			lastsuccessat = coalesce(excluded.lastsuccessat, lastsuccessat),
+			failures = case when excluded.lasterror is null then 0 else failures + 1 end;`)
+	if err != nil {
+		return fmt.Errorf("[store.go] RecordFetch: %w", err)
+	}
+
+	_, err = stmt.Exec(entry.FeedURL, at, errStr, entry.Status)
+	if err != nil {
+		return fmt.Errorf("[store.go] RecordFetch: %w", err)
+	}
+
+	stmt, err = db.Prepare(`delete from fetch_log where feedurl = ?1 and id not in (select id from fetch_log where feedurl = ?1 order by id desc limit ?2);`)
+	if err != nil {
+		return fmt.Errorf("[store.go] RecordFetch: %w", err)
+	}
+
+	_, err = stmt.Exec(entry.FeedURL, fetchLogLimit)
+	if err != nil {
+		return fmt.Errorf("[store.go] RecordFetch: %w", err)
+	}
+
+	return nil
+}
+
+// GetFetchLog returns the most recent fetch attempts for a feed, newest first
+func (sls SQLiteStore) GetFetchLog(feedURL string, limit int) ([]FetchLog, error) {
+	rows, err := sls.db.Query(`select feedurl, fetchedat, status, durationms, error, newitems from fetch_log where feedurl = ? order by id desc limit ?;`, feedURL, limit)
+	if err != nil {
+		return nil, fmt.Errorf("[store.go] GetFetchLog: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []FetchLog
+	for rows.Next() {
+		var l FetchLog
+		var durationMS int64
+		var errNull sql.NullString
+
+		err := rows.Scan(&l.FeedURL, &l.FetchedAt, &l.Status, &durationMS, &errNull, &l.NewItems)
+		if err != nil {
+			return logs, fmt.Errorf("[store.go] GetFetchLog: %w", err)
+		}
+
+		l.Duration = time.Duration(durationMS) * time.Millisecond
+		l.Error = errNull.String
+		logs = append(logs, l)
+	}
+
+	return logs, rows.Err()
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// QueueNotifications adds items to a notification's queue, leaving out any
+// already waiting
+func (sls *SQLiteStore) QueueNotifications(target string, itemIDs []int) error {
+	db := sls.conn()
+
+	stmt, err := db.Prepare(`insert into notify_queue (target, itemid, queuedat) values (?, ?, ?) on conflict do nothing;`)
+	if err != nil {
+		return fmt.Errorf("[store.go] QueueNotifications: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for _, id := range itemIDs {
+		_, err = stmt.Exec(target, id, now)
+		if err != nil {
+			return fmt.Errorf("[store.go] QueueNotifications: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetNotifyQueue returns the items waiting to be sent to a notification, in
+// the order they were found, and when it was last sent anything
+func (sls *SQLiteStore) GetNotifyQueue(target string) ([]Item, time.Time, error) {
+	var sentAt sql.NullTime
+	err := sls.db.QueryRow(`select sentat from notify_sent where target = ?;`, target).Scan(&sentAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, time.Time{}, fmt.Errorf("[store.go] GetNotifyQueue: %w", err)
+	}
+
+	rows, err := sls.db.Query(`select `+itemColumns+` from items join notify_queue q on q.itemid = items.id where q.target = ? order by q.queuedat, items.id;`, target)
+	if err != nil {
+		return nil, sentAt.Time, fmt.Errorf("[store.go] GetNotifyQueue: %w", err)
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		it, err := scanItem(rows)
+		if err != nil {
+			return items, sentAt.Time, fmt.Errorf("[store.go] GetNotifyQueue: %w", err)
+		}
+		items = append(items, it)
+	}
+
+	return items, sentAt.Time, rows.Err()
+}
+
+// MarkNotified takes sent items off a notification's queue and records when
+// it was sent
+func (sls *SQLiteStore) MarkNotified(target string, itemIDs []int, at time.Time) error {
+	db := sls.conn()
+
+	stmt, err := db.Prepare(`delete from notify_queue where target = ? and itemid = ?;`)
+	if err != nil {
+		return fmt.Errorf("[store.go] MarkNotified: %w", err)
+	}
+
+	for _, id := range itemIDs {
+		_, err = stmt.Exec(target, id)
+		if err != nil {
+			return fmt.Errorf("[store.go] MarkNotified: %w", err)
+		}
+	}
+
+	stmt, err = db.Prepare(`insert into notify_sent (target, sentat) values (?, ?) on conflict(target) do update set sentat = excluded.sentat;`)
+	if err != nil {
+		return fmt.Errorf("[store.go] MarkNotified: %w", err)
+	}
+
+	_, err = stmt.Exec(target, at.UTC())
+	if err != nil {
+		return fmt.Errorf("[store.go] MarkNotified: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PrunePolicy selects items to delete, zero values disable a rule
+type PrunePolicy struct {
+	MaxAge         time.Duration
+	MaxItems       int
+	KeepUnread     bool
+	KeepFavourites bool
+}
+
+// itemDate is when an item was published, or first stored if the feed didn't
+// provide a date
+func itemDate(it Item) time.Time {
+	if it.PublishedAt.IsZero() {
+		return it.CreatedAt
+	}
+	return it.PublishedAt
+}
+
+// GetPrunableItems returns the items that policy would delete, oldest first.
+// Only the columns the policy needs are loaded. Dates are compared in Go
+// rather than sqlite as stored timestamps keep the offset they were published
+// with and don't sort as strings.
+func (sls SQLiteStore) GetPrunableItems(p PrunePolicy) ([]Item, error) {
+	rows, err := sls.db.Query(`select id, feedurl, publishedat, createdat, readat, favourite from items;`)
+	if err != nil {
+		return []Item{}, fmt.Errorf("[store.go] GetPrunableItems: %w", err)
+	}
+	defer rows.Close()
+
+	feeds := map[string][]Item{}
+	for rows.Next() {
+		var item Item
+		var publishedAt, createdAt, readAt sql.NullTime
+		err := rows.Scan(&item.ID, &item.FeedURL, &publishedAt, &createdAt, &readAt, &item.Favourite)
+		if err != nil {
+			return []Item{}, fmt.Errorf("[store.go] GetPrunableItems: %w", err)
+		}
+		item.PublishedAt = publishedAt.Time
+		item.CreatedAt = createdAt.Time
+		item.ReadAt = readAt.Time
+
+		feeds[item.FeedURL] = append(feeds[item.FeedURL], item)
+	}
+	if err := rows.Err(); err != nil {
+		return []Item{}, fmt.Errorf("[store.go] GetPrunableItems: %w", err)
+	}
+
+	cutoff := time.Now().Add(-p.MaxAge)
+	var items []Item
+	for _, url := range slices.Sorted(maps.Keys(feeds)) {
+		its := feeds[url]
+		// newest first so the index is the item's rank within its feed
+		slices.SortFunc(its, func(a, b Item) int {
+			return cmp.Or(itemDate(b).Compare(itemDate(a)), cmp.Compare(a.ID, b.ID))
+		})
+
+		var prunable []Item
+		for rank, it := range its {
+			tooMany := p.MaxItems > 0 && rank >= p.MaxItems
+			tooOld := p.MaxAge > 0 && itemDate(it).Before(cutoff)
+			if !tooMany && !tooOld {
+				continue
+			}
+			if (p.KeepUnread && !it.Read()) || (p.KeepFavourites && it.Favourite) {
+				continue
+			}
+			prunable = append(prunable, it)
+		}
+
+		slices.Reverse(prunable)
+		items = append(items, prunable...)
+	}
+
+	return items, nil
+}
+
+// deleteChunkSize keeps DeleteItems under sqlite's bound parameter limit
+const deleteChunkSize = 500
+
+// DeleteItems deletes items and remembers their guids and links in
+// pruned_items, so that fetching a feed which still lists them doesn't store
+// them again as new
+func (sls SQLiteStore) DeleteItems(IDs []int) error {
+	// kept in UTC so ForgetPruned can compare them as strings
+	now := time.Now().UTC()
+	for start := 0; start < len(IDs); start += deleteChunkSize {
+		chunk := IDs[start:min(start+deleteChunkSize, len(IDs))]
+
+		args := make([]any, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ",")
+
+		for _, key := range []string{"guid", "link"} {
+			_, err := sls.db.Exec(`
+				insert or ignore into pruned_items (feedurl, key, prunedat)
+				select feedurl, `+key+`, ? from items
+				where id in (`+placeholders+`) and coalesce(`+key+`, '') != '';
+			`, append([]any{now}, args...)...)
+			if err != nil {
+				return fmt.Errorf("[store.go] DeleteItems: %w", err)
+			}
+		}
+
+		_, err := sls.db.Exec(`delete from items where id in (`+placeholders+`);`, args...)
+		if err != nil {
+			return fmt.Errorf("[store.go] DeleteItems: %w", err)
+		}
+	}
+
+	err := removeOrphans(sls.db)
+	if err != nil {
+		return fmt.Errorf("[store.go] DeleteItems: %w", err)
+	}
+
+	return nil
+}
+
+// WasPruned reports whether an item with the same guid or link in the same
+// feed was deleted by DeleteItems
+func (sls *SQLiteStore) WasPruned(item *Item) (bool, error) {
+	stmt, err := sls.conn().Prepare(`select 1 from pruned_items where feedurl = ? and key in (?, ?) limit 1;`)
+	if err != nil {
+		return false, fmt.Errorf("[store.go] WasPruned: %w", err)
+	}
+	defer stmt.Close()
+
+	var found int
+	err = stmt.QueryRow(item.FeedURL, item.GUID, item.Link).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("[store.go] WasPruned: %w", err)
+	}
+
+	return true, nil
+}
+
+// ForgetPruned drops the record of items pruned before t, so pruned_items
+// doesn't grow forever
+func (sls SQLiteStore) ForgetPruned(before time.Time) error {
+	_, err := sls.db.Exec(`delete from pruned_items where prunedat < ?;`, before.UTC())
+	if err != nil {
+		return fmt.Errorf("[store.go] ForgetPruned: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RemoteItem links a stored item to its copy in a sync backend. The dirty
+// flags are set when read or favourite state changes locally and the backend
+// still needs to be told about it.
+type RemoteItem struct {
+	ItemID         int
+	Backend        string
+	RemoteID       string
+	ReadDirty      bool
+	FavouriteDirty bool
+	Read           bool
+	Favourite      bool
+}
+
+func (r RemoteItem) Dirty() bool {
+	return r.ReadDirty || r.FavouriteDirty
+}
+
+func (sls *SQLiteStore) SetRemoteID(itemID int, backend string, remoteID string) error {
+	stmt, err := sls.conn().Prepare(`insert into remote_items (itemid, backend, remoteid) values (?, ?, ?) on conflict(itemid) do update set backend = excluded.backend, remoteid = excluded.remoteid;`)
+	if err != nil {
+		return fmt.Errorf("[store.go] SetRemoteID: %w", err)
+	}
+
+	_, err = stmt.Exec(itemID, backend, remoteID)
+	if err != nil {
+		return fmt.Errorf("[store.go] SetRemoteID: %w", err)
+	}
+
+	return nil
+}
+
+func (sls SQLiteStore) GetRemoteItems(backend string) ([]RemoteItem, error) {
+	rows, err := sls.db.Query(`select r.itemid, r.backend, r.remoteid, r.readdirty, r.favouritedirty, i.readat is not null, i.favourite from remote_items r join items i on i.id = r.itemid where r.backend = ?;`, backend)
+	if err != nil {
+		return []RemoteItem{}, fmt.Errorf("[store.go] GetRemoteItems: %w", err)
+	}
+	defer rows.Close()
+
+	var ris []RemoteItem
+	for rows.Next() {
+		var ri RemoteItem
+		err := rows.Scan(&ri.ItemID, &ri.Backend, &ri.RemoteID, &ri.ReadDirty, &ri.FavouriteDirty, &ri.Read, &ri.Favourite)
+		if err != nil {
+			return ris, fmt.Errorf("[store.go] GetRemoteItems: %w", err)
+		}
+
+		ris = append(ris, ri)
+	}
+
+	return ris, rows.Err()
+}
+
+// ClearDirty marks local changes to the items as sent to their backend
+func (sls *SQLiteStore) ClearDirty(itemIDs []int) error {
+	if len(itemIDs) == 0 {
+		return nil
+	}
+
+	args := make([]any, len(itemIDs))
+	for i, id := range itemIDs {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(itemIDs)), ",")
+
+	stmt, err := sls.conn().Prepare(`update remote_items set readdirty = 0, favouritedirty = 0 where itemid in (` + placeholders + `);`)
+	if err != nil {
+		return fmt.Errorf("[store.go] ClearDirty: %w", err)
+	}
+
+	_, err = stmt.Exec(args...)
+	if err != nil {
+		return fmt.Errorf("[store.go] ClearDirty: %w", err)
+	}
+
+	return nil
+}
+
+// SetItemState applies read and favourite state that came from a backend.
+// Unlike ToggleRead and ToggleFavourite it doesn't mark the item as dirty and
+// keeps the original read time of items that were already read.
+func (sls *SQLiteStore) SetItemState(ID int, read bool, favourite bool) error {
+	stmt, err := sls.conn().Prepare(`update items set readat = case when ? then coalesce(readat, ?) else null end, favourite = ? where id = ?;`)
+	if err != nil {
+		return fmt.Errorf("[store.go] SetItemState: %w", err)
+	}
+
+	_, err = stmt.Exec(read, time.Now(), favourite, ID)
+	if err != nil {
+		return fmt.Errorf("[store.go] SetItemState: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxRevisions is the number of earlier versions kept per item
+const maxRevisions = 20
+
+// Revision is an earlier version of an item, replaced at RevisedAt
+type Revision struct {
+	ID        int
+	ItemID    int
+	Title     string
+	Content   string
+	RevisedAt time.Time
+}
+
+type storedItem struct {
+	id      int
+	link    string
+	title   string
+	content string
+}
+
+// findStoredItem finds the stored copy of an item from the same feed, by GUID
+// first as publishers change links, then by link
+func findStoredItem(db statementPreparer, item *Item) (storedItem, bool, error) {
+	queries := []struct {
+		where string
+		arg   string
+	}{
+		{"guid = ?", item.GUID},
+		{"link = ?", item.Link},
+	}
+
+	for _, q := range queries {
+		if q.arg == "" {
+			continue
+		}
+
+		stmt, err := db.Prepare(`select id, coalesce(link, ''), coalesce(title, ''), coalesce(content, '') from items where feedurl = ? and ` + q.where + ` order by id limit 1;`)
+		if err != nil {
+			return storedItem{}, false, fmt.Errorf("findStoredItem: %w", err)
+		}
+
+		var s storedItem
+		err = stmt.QueryRow(item.FeedURL, q.arg).Scan(&s.id, &s.link, &s.title, &s.content)
+		if err == nil {
+			return s, true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return storedItem{}, false, fmt.Errorf("findStoredItem: %w", err)
+		}
+	}
+
+	return storedItem{}, false, nil
+}
+
+// isRevision reports whether item changes the title or content of what is
+// stored. Content appearing where there was none isn't counted.
+func (s storedItem) isRevision(item *Item) bool {
+	titleChanged := strings.TrimSpace(s.title) != strings.TrimSpace(item.Title)
+	contentChanged := strings.TrimSpace(s.content) != strings.TrimSpace(item.Content)
+
+	if strings.TrimSpace(s.content) == "" && !titleChanged {
+		return false
+	}
+
+	return titleChanged || contentChanged
+}
+
+// saveRevision keeps the stored version of an item before it is replaced
+func saveRevision(db statementPreparer, s storedItem, revisedAt time.Time) error {
+	stmt, err := db.Prepare(`insert into item_revisions (itemid, title, content, revisedat) values (?, ?, ?, ?);`)
+	if err != nil {
+		return fmt.Errorf("saveRevision: %w", err)
+	}
+
+	_, err = stmt.Exec(s.id, s.title, s.content, revisedAt)
+	if err != nil {
+		return fmt.Errorf("saveRevision: %w", err)
+	}
+
+	stmt, err = db.Prepare(`
+		delete from item_revisions where itemid = ?1 and id not in (
+			select id from item_revisions where itemid = ?1 order by id desc limit ?2
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("saveRevision: %w", err)
+	}
+
+	_, err = stmt.Exec(s.id, maxRevisions)
+	if err != nil {
+		return fmt.Errorf("saveRevision: %w", err)
+	}
+
+	return nil
+}
+
+// GetRevisions returns the earlier versions of an item, newest first
+func (sls SQLiteStore) GetRevisions(itemID int) ([]Revision, error) {
+	rows, err := sls.db.Query(`select id, itemid, title, content, revisedat from item_revisions where itemid = ? order by id desc;`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("[store.go] GetRevisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revs []Revision
+	for rows.Next() {
+		var r Revision
+		err := rows.Scan(&r.ID, &r.ItemID, &r.Title, &r.Content, &r.RevisedAt)
+		if err != nil {
+			return revs, fmt.Errorf("[store.go] GetRevisions: %w", err)
+		}
+		revs = append(revs, r)
+	}
+
+	return revs, rows.Err()
+}
+
+// ClearRevised drops the updated flag once a changed item has been seen
+func (sls SQLiteStore) ClearRevised(ID int) error {
+	_, err := sls.db.Exec(`update items set revisedat = null where id = ?`, ID)
+	if err != nil {
+		return fmt.Errorf("[store.go] ClearRevised: %w", err)
+	}
+
+	return nil
+}
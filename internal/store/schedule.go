@@ -0,0 +1,60 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// SetNextFetch records when a feed is next due to be fetched
+func (sls *SQLiteStore) SetNextFetch(feedURL string, at time.Time) error {
+	stmt, err := sls.conn().Prepare(`insert into feeds (feedurl, nextfetchat) values (?, ?) on conflict(feedurl) do update set nextfetchat = excluded.nextfetchat;`)
+	if err != nil {
+		return fmt.Errorf("[store.go] SetNextFetch: %w", err)
+	}
+
+	_, err = stmt.Exec(feedURL, at.UTC())
+	if err != nil {
+		return fmt.Errorf("[store.go] SetNextFetch: %w", err)
+	}
+
+	return nil
+}
+
+// GetPublishTimes returns when a feed's most recent items were published,
+// newest first. Items without a publish date are left out. The times are
+// sorted in Go as stored timestamps keep their publish offset and don't sort
+// as strings.
+func (sls *SQLiteStore) GetPublishTimes(feedURL string, limit int) ([]time.Time, error) {
+	stmt, err := sls.conn().Prepare(`select publishedat from items where feedurl = ? and publishedat is not null;`)
+	if err != nil {
+		return nil, fmt.Errorf("[store.go] GetPublishTimes: %w", err)
+	}
+
+	rows, err := stmt.Query(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("[store.go] GetPublishTimes: %w", err)
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var t sql.NullTime
+		if err := rows.Scan(&t); err != nil {
+			return nil, fmt.Errorf("[store.go] GetPublishTimes: %w", err)
+		}
+		if t.Valid && !t.Time.IsZero() {
+			times = append(times, t.Time)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("[store.go] GetPublishTimes: %w", err)
+	}
+
+	slices.SortFunc(times, func(a, b time.Time) int {
+		return b.Compare(a)
+	})
+
+	return times[:min(limit, len(times))], nil
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// setupSearchIndex creates the full text index over items if it doesn't exist
+// yet. FTS5 is only compiled into go-sqlite3 with the sqlite_fts5 build tag,
+// so fall back to FTS4 which is always available. Both support the simple
+// MATCH queries used by Search. An FTS4 index is rebuilt with FTS5 once the
+// binary supports it. This lives outside of runMigrations as the statement
+// differs depending on how the binary was built.
+func setupSearchIndex(db *sql.DB) error {
+	var existing string
+	err := db.QueryRow(`select sql from sqlite_master where name = 'items_fts';`).Scan(&existing)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("setupSearchIndex: %w", err)
+	}
+	exists := err == nil
+
+	var fts5 bool
+	err = db.QueryRow(`select sqlite_compileoption_used('ENABLE_FTS5');`).Scan(&fts5)
+	if err != nil {
+		return fmt.Errorf("setupSearchIndex: %w", err)
+	}
+
+	if exists && (!fts5 || !strings.Contains(strings.ToLower(existing), "using fts4")) {
+		return nil
+	}
+
+	module := "fts4"
+	if fts5 {
+		module = "fts5"
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("setupSearchIndex: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		`create virtual table items_fts using ` + module + `(title, content, author);`,
+		// backfill items stored before the index existed
+		`insert into items_fts (rowid, title, content, author) select id, title, case when fulltext != '' then fulltext else content end, author from items;`,
+	}
+	if exists {
+		stmts = slices.Insert(stmts, 0, `drop table items_fts;`)
+	}
+
+	for _, stmt := range stmts {
+		_, err = tx.Exec(stmt)
+		if err != nil {
+			return fmt.Errorf("setupSearchIndex: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("setupSearchIndex: %w", err)
+	}
+
+	return nil
+}
+
+func indexItem(db statementPreparer, itemID int) error {
+	stmt, err := db.Prepare(`delete from items_fts where rowid = ?;`)
+	if err != nil {
+		return fmt.Errorf("indexItem: %w", err)
+	}
+
+	_, err = stmt.Exec(itemID)
+	if err != nil {
+		return fmt.Errorf("indexItem: %w", err)
+	}
+
+	// index the full text of an article in place of the summary once fetched
+	stmt, err = db.Prepare(`insert into items_fts (rowid, title, content, author) select id, title, case when fulltext != '' then fulltext else content end, author from items where id = ?;`)
+	if err != nil {
+		return fmt.Errorf("indexItem: %w", err)
+	}
+
+	_, err = stmt.Exec(itemID)
+	if err != nil {
+		return fmt.Errorf("indexItem: %w", err)
+	}
+
+	return nil
+}
+
+// pruneSearchIndex removes index entries for items that no longer exist
+func pruneSearchIndex(db *sql.DB) error {
+	_, err := db.Exec(`delete from items_fts where rowid not in (select id from items);`)
+	if err != nil {
+		return fmt.Errorf("pruneSearchIndex: %w", err)
+	}
+
+	return nil
+}
+
+// Search returns items whose title, content or author match query, newest
+// first. query uses the sqlite full text query syntax, e.g. `foo AND "bar baz"`.
+func (sls SQLiteStore) Search(query string) ([]Item, error) {
+	rows, err := sls.db.Query(`select `+itemColumns+` from items where id in (select rowid from items_fts where items_fts match ?) order by coalesce(publishedat, createdat) desc;`, query)
+	if err != nil {
+		return []Item{}, fmt.Errorf("[store.go] Search: %w", err)
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return items, fmt.Errorf("[store.go] Search: %w", err)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// QuoteSearchTerm turns user input into a single phrase query so that
+// characters with a meaning in the query syntax are matched literally.
+func QuoteSearchTerm(term string) string {
+	return `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+}
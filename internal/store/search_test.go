@@ -0,0 +1,35 @@
+//go:build sqlite_fts5
+
+package store
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guyfedwards/nom/v2/internal/test"
+)
+
+func TestSearchIndexUpgrade(t *testing.T) {
+	s, err := NewInMemorySQLiteStore()
+	test.HandleError(t, err)
+
+	it := Item{FeedURL: "a", Link: "a/1", Title: "sqlite upgrades"}
+	_, err = s.UpsertItem(&it)
+	test.HandleError(t, err)
+
+	// stand in for an index made by a binary built without FTS5
+	_, err = s.db.Exec(`drop table items_fts; create virtual table items_fts using fts4(title, content, author);`)
+	test.HandleError(t, err)
+
+	err = setupSearchIndex(s.db)
+	test.HandleError(t, err)
+
+	var stmt string
+	err = s.db.QueryRow(`select sql from sqlite_master where name = 'items_fts';`).Scan(&stmt)
+	test.HandleError(t, err)
+	test.Equal(t, true, strings.Contains(stmt, "fts5"), "index should be rebuilt with FTS5")
+
+	items, err := s.Search("upgrades")
+	test.HandleError(t, err)
+	test.Equal(t, 1, len(items), "existing items should be indexed")
+}
@@ -2,10 +2,10 @@ package store
 
 import (
 	"database/sql"
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -28,24 +28,101 @@ type Item struct {
 	PublishedAt time.Time
 	UpdatedAt   time.Time
 	CreatedAt   time.Time
+	// Hidden items were muted by a rule and are left out of item lists
+	Hidden bool
+	Labels []string
+	// DuplicateOf is the ID of the item from another feed this is a copy of
+	DuplicateOf int
+	// Enclosures are only loaded by GetItemByID
+	Enclosures []Enclosure
+	// Image is a thumbnail for the item
+	Image string
+	// FullText is the article fetched from Link for feeds that only carry a
+	// summary. Like Enclosures it's only loaded by GetItemByID.
+	FullText string
+	// RevisedAt is when the title or content last changed after the item
+	// was first stored
+	RevisedAt time.Time
+	// Sources are the names of the feeds an item and its copies came from,
+	// set when duplicates are collapsed
+	Sources []string
 }
 
 func (i Item) Read() bool {
 	return !i.ReadAt.IsZero()
 }
 
+// Updated reports whether the item changed since it was read
+func (i Item) Updated() bool {
+	return !i.RevisedAt.IsZero() && i.RevisedAt.After(i.ReadAt)
+}
+
+// FeedMeta is per-feed state that is kept between fetches
+type FeedMeta struct {
+	FeedURL       string
+	ETag          string
+	LastModified  string
+	LastFetchedAt time.Time
+	// LastError is empty if the last fetch succeeded
+	LastError     string
+	LastStatus    int
+	LastSuccessAt time.Time
+	// Failures is the number of failed fetches since the last success
+	Failures int
+	// UpdateHint is how often the feed last asked to be fetched, from its
+	// <ttl> or sy:updatePeriod
+	UpdateHint time.Duration
+	// NextFetchAt is when the feed is next due to be fetched, zero if it
+	// hasn't been scheduled
+	NextFetchAt time.Time
+}
+
+// FeedStats are item counts for a feed
+type FeedStats struct {
+	FeedURL string
+	Items   int
+	Unread  int
+}
+
 type Store interface {
-	UpsertItem(item *Item) error
+	// UpsertItem reports whether the item was new
+	UpsertItem(item *Item) (bool, error)
 	BeginBatch() error
 	EndBatch() error
 	GetAllItems(ordering string) ([]Item, error)
+	GetItemSummaries() ([]Item, error)
 	GetItemByID(ID int) (Item, error)
 	GetAllFeedURLs() ([]string, error)
 	ToggleRead(ID int) error
+	SetRead(ID int, read bool) error
 	MarkAllRead() error
 	ToggleFavourite(ID int) error
 	DeleteByFeedURL(feedurl string, incFavourites bool) error
 	CountUnread() (int, error)
+	Search(query string) ([]Item, error)
+	GetPrunableItems(p PrunePolicy) ([]Item, error)
+	DeleteItems(IDs []int) error
+	WasPruned(item *Item) (bool, error)
+	ForgetPruned(before time.Time) error
+	SetRemoteID(itemID int, backend string, remoteID string) error
+	GetRemoteItems(backend string) ([]RemoteItem, error)
+	ClearDirty(itemIDs []int) error
+	SetItemState(ID int, read bool, favourite bool) error
+	GetAllFeedMeta() (map[string]FeedMeta, error)
+	UpsertFeedMeta(meta FeedMeta) error
+	SetNextFetch(feedURL string, at time.Time) error
+	GetPublishTimes(feedURL string, limit int) ([]time.Time, error)
+	QueueNotifications(target string, itemIDs []int) error
+	GetNotifyQueue(target string) ([]Item, time.Time, error)
+	MarkNotified(target string, itemIDs []int, at time.Time) error
+	RecordFetch(entry FetchLog) error
+	GetFetchLog(feedURL string, limit int) ([]FetchLog, error)
+	GetFeedStats() (map[string]FeedStats, error)
+	SetHidden(ID int, hidden bool) error
+	AddLabel(ID int, label string) error
+	SetFullText(ID int, content string) error
+	GetRevisions(itemID int) ([]Revision, error)
+	ClearRevised(ID int) error
 }
 
 type SQLiteStore struct {
@@ -70,6 +147,16 @@ func NewInMemorySQLiteStore() (*SQLiteStore, error) {
 		return nil, fmt.Errorf("NewInMemorySQLiteStore: %w", err)
 	}
 
+	err = setupSearchIndex(db)
+	if err != nil {
+		return nil, fmt.Errorf("NewInMemorySQLiteStore: %w", err)
+	}
+
+	err = backfillDuplicateKeys(db)
+	if err != nil {
+		return nil, fmt.Errorf("NewInMemorySQLiteStore: %w", err)
+	}
+
 	return &SQLiteStore{
 		db: db,
 	}, nil
@@ -80,7 +167,9 @@ func NewSQLiteStore(basePath string, dbName string) (*SQLiteStore, error) {
 
 	info, _ := os.Stat(dbpath)
 
-	db, err := sql.Open("sqlite3", dbpath)
+	// wait on other writers, e.g. nom serve and the TUI sharing a database,
+	// rather than failing straight away with "database is locked"
+	db, err := sql.Open("sqlite3", dbpath+"?_busy_timeout=5000")
 	if err != nil {
 		return nil, fmt.Errorf("NewSQLiteStore: %w", err)
 	}
@@ -99,6 +188,16 @@ func NewSQLiteStore(basePath string, dbName string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("NewSQLiteStore: %w", err)
 	}
 
+	err = setupSearchIndex(db)
+	if err != nil {
+		return nil, fmt.Errorf("NewSQLiteStore: %w", err)
+	}
+
+	err = backfillDuplicateKeys(db)
+	if err != nil {
+		return nil, fmt.Errorf("NewSQLiteStore: %w", err)
+	}
+
 	return &SQLiteStore{
 		path: dbpath,
 		db:   db,
@@ -138,6 +237,35 @@ func runMigrations(db *sql.DB) (err error) {
 	migrations := []string{
 		`alter table items add favourite boolean not null default 0;`,
 		`alter table items add guid text`,
+		`create table feeds (feedurl text primary key, etag text, lastmodified text)`,
+		`create table remote_items (itemid integer primary key, backend text not null, remoteid text not null, readdirty boolean not null default 0, favouritedirty boolean not null default 0)`,
+		`alter table feeds add lastfetchedat datetime`,
+		`alter table feeds add lasterror text`,
+		`create table fetch_log (id integer primary key, feedurl text not null, fetchedat datetime not null, status integer, durationms integer, error text, newitems integer not null default 0)`,
+		`create index fetch_log_feedurl on fetch_log (feedurl, id)`,
+		`alter table feeds add laststatus integer`,
+		`alter table feeds add lastsuccessat datetime`,
+		`alter table feeds add failures integer not null default 0`,
+		`alter table items add hidden boolean not null default 0`,
+		`alter table items add labels text not null default ''`,
+		`alter table items add normlink text`,
+		`alter table items add simhash integer`,
+		`alter table items add duplicateof integer`,
+		`create index items_normlink on items (normlink)`,
+		`create index items_guid on items (guid)`,
+		`create index items_duplicateof on items (duplicateof)`,
+		`create table item_revisions (id integer primary key, itemid integer not null, title text, content text, revisedat datetime not null)`,
+		`create index item_revisions_itemid on item_revisions (itemid, id)`,
+		`alter table items add revisedat datetime`,
+		`create table enclosures (id integer primary key, itemid integer not null, url text not null, type text not null default '', length integer not null default 0, duration text not null default '')`,
+		`create index enclosures_itemid on enclosures (itemid)`,
+		`alter table items add image text not null default ''`,
+		`alter table items add fulltext text not null default ''`,
+		`alter table feeds add updatehint integer not null default 0`,
+		`alter table feeds add nextfetchat datetime`,
+		`create table notify_queue (target text not null, itemid integer not null, queuedat datetime not null, primary key (target, itemid))`,
+		`create table notify_sent (target text primary key, sentat datetime not null)`,
+		`create table pruned_items (feedurl text not null, key text not null, prunedat datetime not null, primary key (feedurl, key))`,
 	}
 
 	tx, _ := db.Begin()
@@ -201,7 +329,16 @@ func (sls *SQLiteStore) EndBatch() error {
 	return nil
 }
 
-func (sls *SQLiteStore) UpsertItem(item *Item) error {
+// conn returns the current batch if there is one, so that writes made while
+// a batch is open don't wait on its lock
+func (sls *SQLiteStore) conn() statementPreparer {
+	if sls.batch != nil {
+		return sls.batch
+	}
+	return sls.db
+}
+
+func (sls *SQLiteStore) UpsertItem(item *Item) (bool, error) {
 	if sls.batch != nil {
 		return sls.upsertItem(sls.batch, item)
 	}
@@ -213,51 +350,124 @@ type statementPreparer interface {
 	Prepare(query string) (*sql.Stmt, error)
 }
 
-func (sls *SQLiteStore) upsertItem(db statementPreparer, item *Item) error {
-	stmt, err := db.Prepare(`select count(id), id from items where feedurl = ? and link = ?;`)
+func (sls *SQLiteStore) upsertItem(db statementPreparer, item *Item) (bool, error) {
+	stored, found, err := findStoredItem(db, item)
 	if err != nil {
-		return fmt.Errorf("sqlite.go: could not prepare query: %w", err)
+		return false, fmt.Errorf("store.go: write %w", err)
 	}
 
-	var count int
-	var id sql.NullInt32
-	err = stmt.QueryRow(item.FeedURL, item.Link).Scan(&count, &id)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("store.go: write %w", err)
-	}
-	if count == 0 {
-		stmt, err = db.Prepare(`insert into items (feedurl, guid, link, title, content, author, publishedat, createdat, updatedat) values (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	var stmt *sql.Stmt
+	inserted := !found
+	if inserted {
+		keys := newDuplicateKeys(item)
+		duplicateOf, err := findDuplicate(db, item, keys)
+		if err != nil {
+			return false, fmt.Errorf("sqlite.go: %w", err)
+		}
+		item.DuplicateOf = duplicateOf
+
+		stmt, err = db.Prepare(`insert into items (feedurl, guid, link, title, content, author, publishedat, createdat, updatedat, normlink, simhash, duplicateof, image) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, nullif(?, 0), ?)`)
 		if err != nil {
-			return fmt.Errorf("sqlite.go: could not prepare query: %w", err)
+			return false, fmt.Errorf("sqlite.go: could not prepare query: %w", err)
 		}
 
-		result, err := stmt.Exec(item.FeedURL, item.GUID, item.Link, item.Title, item.Content, item.Author, item.PublishedAt, time.Now(), time.Now())
+		result, err := stmt.Exec(item.FeedURL, item.GUID, item.Link, item.Title, item.Content, item.Author, item.PublishedAt, time.Now(), time.Now(), keys.normlink, keys.simhash, duplicateOf, item.Image)
 		if err != nil {
-			return fmt.Errorf("sqlite.go: Upsert failed: %w", err)
+			return false, fmt.Errorf("sqlite.go: Upsert failed: %w", err)
 		}
 		lastID, err := result.LastInsertId()
 		if err != nil {
-			return fmt.Errorf("sqlite.go: No inserted ID: %w", err)
+			return false, fmt.Errorf("sqlite.go: No inserted ID: %w", err)
 		}
 		item.ID = int(lastID)
 	} else {
-		stmt, err = db.Prepare(`update items set title = ?, content = ?, updatedat = ? where id = ?`)
+		now := time.Now()
+
+		var revisedAt sql.NullTime
+		if stored.isRevision(item) {
+			err = saveRevision(db, stored, now)
+			if err != nil {
+				return false, fmt.Errorf("sqlite.go: %w", err)
+			}
+			revisedAt = sql.NullTime{Time: now, Valid: true}
+		}
+
+		// matched on GUID, so the link may have moved
+		link := stored.link
+		if item.Link != "" {
+			link = item.Link
+		}
+
+		stmt, err = db.Prepare(`
+			update items set title = ?, content = ?, link = ?, normlink = ?, guid = coalesce(nullif(?, ''), guid), updatedat = ?, revisedat = coalesce(?, revisedat),
+				image = coalesce(nullif(?, ''), image)
+			where id = ?
+		`)
 		if err != nil {
-			return fmt.Errorf("sqlite.go: could not prepare query: %w", err)
+			return false, fmt.Errorf("sqlite.go: could not prepare query: %w", err)
 		}
 
-		_, err = stmt.Exec(item.Title, item.Content, time.Now(), id)
+		_, err = stmt.Exec(item.Title, item.Content, link, NormaliseLink(link), item.GUID, now, revisedAt, item.Image, stored.id)
 		if err != nil {
-			return fmt.Errorf("sqlite.go: Upsert failed: %w", err)
+			return false, fmt.Errorf("sqlite.go: Upsert failed: %w", err)
 		}
+		item.ID = stored.id
+		item.Link = link
+		item.RevisedAt = revisedAt.Time
 	}
-	return nil
+
+	err = saveEnclosures(db, item.ID, item.Enclosures)
+	if err != nil {
+		return false, fmt.Errorf("sqlite.go: %w", err)
+	}
+
+	err = indexItem(db, item.ID)
+	if err != nil {
+		return false, fmt.Errorf("sqlite.go: %w", err)
+	}
+
+	return inserted, nil
+}
+
+const itemColumns = `id, feedurl, guid, link, title, content, author, readat, favourite, publishedat, createdat, updatedat, hidden, labels, duplicateof, revisedat, image`
+
+// This interface is implemented by both sql.Row and sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanItem(r rowScanner) (Item, error) {
+	var item Item
+	var readAtNull sql.NullTime
+	var publishedAtNull sql.NullTime
+	var linkNull sql.NullString
+	var guidNull sql.NullString
+	var labels string
+	var duplicateOfNull sql.NullInt64
+	var revisedAtNull sql.NullTime
+
+	err := r.Scan(&item.ID, &item.FeedURL, &guidNull, &linkNull, &item.Title, &item.Content, &item.Author, &readAtNull, &item.Favourite, &publishedAtNull, &item.CreatedAt, &item.UpdatedAt, &item.Hidden, &labels, &duplicateOfNull, &revisedAtNull, &item.Image)
+	if err != nil {
+		return Item{}, err
+	}
+
+	item.GUID = guidNull.String
+	item.Link = linkNull.String
+	item.ReadAt = readAtNull.Time
+	item.PublishedAt = publishedAtNull.Time
+	item.DuplicateOf = int(duplicateOfNull.Int64)
+	item.RevisedAt = revisedAtNull.Time
+	if labels != "" {
+		item.Labels = strings.Split(labels, ",")
+	}
+
+	return item, nil
 }
 
 // TODO: pagination
 func (sls SQLiteStore) GetAllItems(ordering string) ([]Item, error) {
 	itemStmt := `
-		select id, feedurl, guid, link, title, content, author, readat, favourite, publishedat, createdat, updatedat from items order by readat is not null asc, coalesce(publishedat, createdat) %s;
+		select ` + itemColumns + ` from items order by readat is not null asc, coalesce(publishedat, createdat) %s;
 	`
 
 	var stmt string
@@ -276,43 +486,100 @@ func (sls SQLiteStore) GetAllItems(ordering string) ([]Item, error) {
 
 	var items []Item
 	for rows.Next() {
-		var item Item
-		var readAtNull sql.NullTime
-		var publishedAtNull sql.NullTime
-		var linkNull sql.NullString
-		var guidNull sql.NullString
-
-		if err := rows.Scan(&item.ID, &item.FeedURL, &guidNull, &linkNull, &item.Title, &item.Content, &item.Author, &readAtNull, &item.Favourite, &publishedAtNull, &item.CreatedAt, &item.UpdatedAt); err != nil {
+		item, err := scanItem(rows)
+		if err != nil {
 			fmt.Println("errrerre: ", err)
 			continue
 		}
 
-		item.GUID = guidNull.String
-		item.Link = linkNull.String
-		item.ReadAt = readAtNull.Time
-		item.PublishedAt = publishedAtNull.Time
-
 		items = append(items, item)
 	}
 
 	return items, nil
 }
 
-func (sls SQLiteStore) ToggleRead(ID int) error {
-	stmt, _ := sls.db.Prepare(`update items set readat = case when readat is null then ? else null end where id = ?`)
+// GetItemSummaries returns every item with only the fields filters match on,
+// leaving out the title and content so it's cheap enough to run as a filter
+// is typed
+func (sls SQLiteStore) GetItemSummaries() ([]Item, error) {
+	rows, err := sls.db.Query(`select id, author, readat, favourite, publishedat, createdat, labels from items;`)
+	if err != nil {
+		return []Item{}, fmt.Errorf("[store.go] GetItemSummaries: %w", err)
+	}
+	defer rows.Close()
 
-	_, err := stmt.Exec(time.Now(), ID)
+	var items []Item
+	for rows.Next() {
+		var item Item
+		var author sql.NullString
+		var readAt, publishedAt, createdAt sql.NullTime
+		var labels string
+		err := rows.Scan(&item.ID, &author, &readAt, &item.Favourite, &publishedAt, &createdAt, &labels)
+		if err != nil {
+			return items, fmt.Errorf("[store.go] GetItemSummaries: %w", err)
+		}
+
+		item.Author = author.String
+		item.ReadAt = readAt.Time
+		item.PublishedAt = publishedAt.Time
+		item.CreatedAt = createdAt.Time
+		if labels != "" {
+			item.Labels = strings.Split(labels, ",")
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// duplicateGroup selects the IDs of an item and its copies in other feeds
+const duplicateGroup = `select id from items where coalesce(duplicateof, id) = (select coalesce(duplicateof, id) from items where id = ?1)`
+
+// ToggleRead toggles an item, and sets its copies in other feeds to match
+func (sls SQLiteStore) ToggleRead(ID int) error {
+	var readAt sql.NullTime
+	err := sls.db.QueryRow(`select readat from items where id = ?`, ID).Scan(&readAt)
 	if err != nil {
 		return fmt.Errorf("[store.go] ToggleRead: %w", err)
 	}
 
+	return sls.SetRead(ID, !readAt.Valid)
+}
+
+// SetRead marks an item and its copies in other feeds read or unread. Unlike
+// ToggleRead it is safe to repeat, e.g. for every item of a feed when copies
+// are among them. Only items that change are marked dirty for backends.
+func (sls SQLiteStore) SetRead(ID int, read bool) error {
+	var readAt any
+	changing := `readat is not null`
+	if read {
+		readAt = time.Now()
+		changing = `readat is null`
+	}
+
+	_, err := sls.db.Exec(`update remote_items set readdirty = 1 where itemid in (select id from items where id in (`+duplicateGroup+`) and `+changing+`)`, ID)
+	if err != nil {
+		return fmt.Errorf("[store.go] SetRead: %w", err)
+	}
+
+	_, err = sls.db.Exec(`update items set readat = ?2 where id in (`+duplicateGroup+`) and `+changing, ID, readAt)
+	if err != nil {
+		return fmt.Errorf("[store.go] SetRead: %w", err)
+	}
+
 	return nil
 }
 
 func (sls SQLiteStore) MarkAllRead() error {
+	_, err := sls.db.Exec(`update remote_items set readdirty = 1 where itemid in (select id from items where readat is null)`)
+	if err != nil {
+		return fmt.Errorf("[store.go] MarkAllRead: %w", err)
+	}
+
 	stmt, _ := sls.db.Prepare(`update items set readat = ? where readat is null`)
 
-	_, err := stmt.Exec(time.Now())
+	_, err = stmt.Exec(time.Now())
 	if err != nil {
 		return fmt.Errorf("[store.go] MarkAllRead: %w", err)
 	}
@@ -328,6 +595,11 @@ func (sls SQLiteStore) ToggleFavourite(ID int) error {
 		return fmt.Errorf("[store.go] ToggleFavourite: %w", err)
 	}
 
+	_, err = sls.db.Exec(`update remote_items set favouritedirty = 1 where itemid = ?`, ID)
+	if err != nil {
+		return fmt.Errorf("[store.go] ToggleFavourite: %w", err)
+	}
+
 	return nil
 }
 
@@ -368,37 +640,92 @@ func (sls SQLiteStore) DeleteByFeedURL(feedurl string, incFavourites bool) error
 		return fmt.Errorf("[store.go] DeleteByFeedURL: %w", err)
 	}
 
+	err = removeOrphans(sls.db)
+	if err != nil {
+		return fmt.Errorf("[store.go] DeleteByFeedURL: %w", err)
+	}
+
+	// drop cache headers too, otherwise re-adding the feed would get a 304
+	// and never repopulate the deleted items
+	_, err = sls.db.Exec(`delete from feeds where feedurl = ?;`, feedurl)
+	if err != nil {
+		return fmt.Errorf("[store.go] DeleteByFeedURL: %w", err)
+	}
+
+	_, err = sls.db.Exec(`delete from fetch_log where feedurl = ?;`, feedurl)
+	if err != nil {
+		return fmt.Errorf("[store.go] DeleteByFeedURL: %w", err)
+	}
+
+	_, err = sls.db.Exec(`delete from pruned_items where feedurl = ?;`, feedurl)
+	if err != nil {
+		return fmt.Errorf("[store.go] DeleteByFeedURL: %w", err)
+	}
+
+	return nil
+}
+
+// removeOrphans cleans up rows in other tables that refer to deleted items
+func removeOrphans(db *sql.DB) error {
+	err := pruneSearchIndex(db)
+	if err != nil {
+		return fmt.Errorf("removeOrphans: %w", err)
+	}
+
+	_, err = db.Exec(`delete from remote_items where itemid not in (select id from items);`)
+	if err != nil {
+		return fmt.Errorf("removeOrphans: %w", err)
+	}
+
+	_, err = db.Exec(`delete from enclosures where itemid not in (select id from items);`)
+	if err != nil {
+		return fmt.Errorf("removeOrphans: %w", err)
+	}
+
+	_, err = db.Exec(`delete from item_revisions where itemid not in (select id from items);`)
+	if err != nil {
+		return fmt.Errorf("removeOrphans: %w", err)
+	}
+
+	_, err = db.Exec(`delete from notify_queue where itemid not in (select id from items);`)
+	if err != nil {
+		return fmt.Errorf("removeOrphans: %w", err)
+	}
+
+	// copies of a deleted item stand on their own again
+	_, err = db.Exec(`update items set duplicateof = null where duplicateof not in (select id from items);`)
+	if err != nil {
+		return fmt.Errorf("removeOrphans: %w", err)
+	}
+
 	return nil
 }
 
 func (sls SQLiteStore) GetItemByID(ID int) (Item, error) {
 	var stmt *sql.Stmt
-	stmt, _ = sls.db.Prepare(`select id, feedurl, guid, link, title, content, author, readat, favourite, publishedat, createdat, updatedat from items where id = ?;`)
+	stmt, _ = sls.db.Prepare(`select ` + itemColumns + ` from items where id = ?;`)
 
-	var i Item
-	var readAtNull sql.NullTime
-	var publishedAtNull sql.NullTime
-	var linkNull sql.NullString
-	var guidNull sql.NullString
-
-	r := stmt.QueryRow(ID)
+	i, err := scanItem(stmt.QueryRow(ID))
+	if err != nil {
+		return Item{}, fmt.Errorf("[store.go] GetItemByID: %w", err)
+	}
 
-	err := r.Scan(&i.ID, &i.FeedURL, &guidNull, &linkNull, &i.Title, &i.Content, &i.Author, &readAtNull, &i.Favourite, &publishedAtNull, &i.CreatedAt, &i.UpdatedAt)
+	i.Enclosures, err = sls.getEnclosures(ID)
 	if err != nil {
 		return Item{}, fmt.Errorf("[store.go] GetItemByID: %w", err)
 	}
 
-	i.Link = linkNull.String
-	i.GUID = guidNull.String
-	i.ReadAt = readAtNull.Time
-	i.PublishedAt = publishedAtNull.Time
+	err = sls.db.QueryRow(`select fulltext from items where id = ?;`, ID).Scan(&i.FullText)
+	if err != nil {
+		return Item{}, fmt.Errorf("[store.go] GetItemByID: %w", err)
+	}
 
 	return i, nil
 }
 
 func (sls SQLiteStore) CountUnread() (int, error) {
 	var stmt *sql.Stmt
-	stmt, _ = sls.db.Prepare(`select count(*) from items where readat is null;`)
+	stmt, _ = sls.db.Prepare(`select count(*) from items where readat is null and hidden = 0;`)
 	var count int
 
 	r := stmt.QueryRow()
@@ -410,3 +737,140 @@ func (sls SQLiteStore) CountUnread() (int, error) {
 
 	return count, nil
 }
+
+func (sls SQLiteStore) GetAllFeedMeta() (map[string]FeedMeta, error) {
+	metas := map[string]FeedMeta{}
+
+	rows, err := sls.db.Query(`select feedurl, etag, lastmodified, lastfetchedat, lasterror, laststatus, lastsuccessat, failures, updatehint, nextfetchat from feeds;`)
+	if err != nil {
+		return metas, fmt.Errorf("[store.go] GetAllFeedMeta: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m FeedMeta
+		var etagNull sql.NullString
+		var lastModifiedNull sql.NullString
+		var lastFetchedNull sql.NullTime
+		var lastErrorNull sql.NullString
+		var lastStatusNull sql.NullInt64
+		var lastSuccessNull sql.NullTime
+		var updateHintSecs int64
+		var nextFetchNull sql.NullTime
+
+		err := rows.Scan(&m.FeedURL, &etagNull, &lastModifiedNull, &lastFetchedNull, &lastErrorNull, &lastStatusNull, &lastSuccessNull, &m.Failures, &updateHintSecs, &nextFetchNull)
+		if err != nil {
+			return metas, fmt.Errorf("[store.go] GetAllFeedMeta: %w", err)
+		}
+
+		m.ETag = etagNull.String
+		m.LastModified = lastModifiedNull.String
+		m.LastFetchedAt = lastFetchedNull.Time
+		m.LastError = lastErrorNull.String
+		m.LastStatus = int(lastStatusNull.Int64)
+		m.LastSuccessAt = lastSuccessNull.Time
+		m.UpdateHint = time.Duration(updateHintSecs) * time.Second
+		m.NextFetchAt = nextFetchNull.Time
+		metas[m.FeedURL] = m
+	}
+
+	return metas, nil
+}
+
+func (sls *SQLiteStore) UpsertFeedMeta(meta FeedMeta) error {
+	stmt, err := sls.conn().Prepare(`insert into feeds (feedurl, etag, lastmodified, updatehint) values (?, ?, ?, ?) on conflict(feedurl) do update set etag = excluded.etag, lastmodified = excluded.lastmodified, updatehint = excluded.updatehint;`)
+	if err != nil {
+		return fmt.Errorf("[store.go] UpsertFeedMeta: %w", err)
+	}
+
+	_, err = stmt.Exec(meta.FeedURL, meta.ETag, meta.LastModified, int64(meta.UpdateHint/time.Second))
+	if err != nil {
+		return fmt.Errorf("[store.go] UpsertFeedMeta: %w", err)
+	}
+
+	return nil
+}
+
+func (sls SQLiteStore) GetFeedStats() (map[string]FeedStats, error) {
+	stats := map[string]FeedStats{}
+
+	rows, err := sls.db.Query(`select feedurl, count(*), count(*) filter (where readat is null) from items group by feedurl;`)
+	if err != nil {
+		return stats, fmt.Errorf("[store.go] GetFeedStats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s FeedStats
+
+		err := rows.Scan(&s.FeedURL, &s.Items, &s.Unread)
+		if err != nil {
+			return stats, fmt.Errorf("[store.go] GetFeedStats: %w", err)
+		}
+
+		stats[s.FeedURL] = s
+	}
+
+	return stats, rows.Err()
+}
+
+func (sls *SQLiteStore) SetHidden(ID int, hidden bool) error {
+	stmt, err := sls.conn().Prepare(`update items set hidden = ? where id = ?;`)
+	if err != nil {
+		return fmt.Errorf("[store.go] SetHidden: %w", err)
+	}
+
+	_, err = stmt.Exec(hidden, ID)
+	if err != nil {
+		return fmt.Errorf("[store.go] SetHidden: %w", err)
+	}
+
+	return nil
+}
+
+// SetFullText caches the article extracted from an item's link, which is
+// searched instead of the feed's summary
+func (sls *SQLiteStore) SetFullText(ID int, content string) error {
+	db := sls.conn()
+
+	stmt, err := db.Prepare(`update items set fulltext = ? where id = ?;`)
+	if err != nil {
+		return fmt.Errorf("[store.go] SetFullText: %w", err)
+	}
+
+	_, err = stmt.Exec(content, ID)
+	if err != nil {
+		return fmt.Errorf("[store.go] SetFullText: %w", err)
+	}
+
+	err = indexItem(db, ID)
+	if err != nil {
+		return fmt.Errorf("[store.go] SetFullText: %w", err)
+	}
+
+	return nil
+}
+
+// AddLabel adds a label to an item, if it doesn't have it already. Labels are
+// stored comma separated, so commas in label are dropped.
+func (sls *SQLiteStore) AddLabel(ID int, label string) error {
+	label = strings.TrimSpace(strings.ReplaceAll(label, ",", ""))
+	if label == "" {
+		return nil
+	}
+
+	stmt, err := sls.conn().Prepare(`
+		update items set labels = case when labels = '' then ?1 else labels || ',' || ?1 end
+		where id = ?2 and instr(',' || labels || ',', ',' || ?1 || ',') = 0;
+	`)
+	if err != nil {
+		return fmt.Errorf("[store.go] AddLabel: %w", err)
+	}
+
+	_, err = stmt.Exec(label, ID)
+	if err != nil {
+		return fmt.Errorf("[store.go] AddLabel: %w", err)
+	}
+
+	return nil
+}